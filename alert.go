@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// How long the screen stays inverted when it flashes.
+const flashDuration = 150 * time.Millisecond
+
+// Escape codes that invert the colors of the whole screen, and put them back.
+const (
+	flashOn  = "\x1b[?5h"
+	flashOff = "\x1b[?5l"
+)
+
+// Where alerts are written: the terminal the program is drawn on.
+var alertOutput io.Writer = os.Stdout
+
+// Represents how the user is told the time ran out.
+type Alert int16
+
+const (
+	NO_ALERT Alert = iota // Nothing beyond the stats screen
+	BELL                  // Ring the terminal bell
+	FLASH                 // Flash the screen
+)
+
+// Every alert, in the order they are listed to the user.
+var alerts = []Alert{NO_ALERT, BELL, FLASH}
+
+// Get the name of an alert, as used on the command line and in the config
+// file.
+func (a Alert) String() string {
+	switch a {
+	case BELL:
+		return "bell"
+	case FLASH:
+		return "flash"
+	default:
+		return "off"
+	}
+}
+
+// Get an alert from its name.
+func parseAlert(name string) (Alert, error) {
+	for _, a := range alerts {
+		if a.String() == name {
+			return a, nil
+		}
+	}
+
+	return NO_ALERT, fmt.Errorf("unknown alert: %v", name)
+}
+
+// Allows the alert to be read from the config file by name.
+func (a *Alert) UnmarshalText(text []byte) error {
+	alert, err := parseAlert(string(text))
+	*a = alert
+	return err
+}
+
+// Get the command that tells the user the time ran out, so they notice even
+// if they're looking away. The screen doesn't flash with reduced motion on;
+// the bell rings instead.
+func (m Model) alert() tea.Cmd {
+	alert := m.settings.alert
+	if alert == FLASH && m.settings.reducedMotion {
+		alert = BELL
+	}
+
+	switch alert {
+	case BELL:
+		return func() tea.Msg {
+			io.WriteString(alertOutput, "\a")
+			return nil
+		}
+	case FLASH:
+		return func() tea.Msg {
+			io.WriteString(alertOutput, flashOn)
+			time.Sleep(flashDuration)
+			io.WriteString(alertOutput, flashOff)
+			return nil
+		}
+	default:
+		return nil
+	}
+}
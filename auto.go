@@ -0,0 +1,84 @@
+package main
+
+// Results needed at the current level before moving up.
+const autoWindow = 3
+
+// Average accuracy needed to move up a level.
+const raiseAccuracy = 96.0
+
+// Accuracy below which the level is lowered right away.
+const lowerAccuracy = 90.0
+
+// Describes how difficult a generated prompt is.
+type level struct {
+	tier        int     // Number of most common words to pick from
+	punctuation float64 // Chance that a word is followed by punctuation
+	words       int     // Number of words in the prompt
+}
+
+// Difficulty levels used by auto mode, easiest first. Levels are numbered
+// from 1 so that 0 can mean auto mode was off.
+var levels = []level{
+	{tier: 50, punctuation: 0, words: 15},
+	{tier: 100, punctuation: 0, words: 25},
+	{tier: 200, punctuation: 0, words: 35},
+	{tier: 200, punctuation: 0.1, words: 40},
+	{tier: 200, punctuation: 0.2, words: 50},
+	{tier: 200, punctuation: 0.3, words: 60},
+}
+
+// Get the settings for a difficulty level.
+func levelSettings(n int) level {
+	return levels[n-1]
+}
+
+// Pick the difficulty level for the next test in auto mode.
+//
+// The level goes up once the user has been consistently accurate at the
+// current level without slowing down, and goes down as soon as a test at the
+// current level goes badly, keeping tests challenging but not frustrating.
+func nextLevel(results []Result) int {
+	var recent []Result
+	for i := len(results) - 1; i >= 0 && len(recent) < autoWindow; i-- {
+		r := results[i]
+		if r.Level == 0 || r.Incomplete {
+			continue
+		}
+
+		if len(recent) > 0 && r.Level != recent[0].Level {
+			break
+		}
+
+		recent = append(recent, r)
+	}
+
+	if len(recent) == 0 {
+		return 1
+	}
+
+	current := recent[0].Level
+	latest := recent[0]
+
+	if latest.Accuracy < lowerAccuracy {
+		return max(current-1, 1)
+	}
+
+	if len(recent) < autoWindow {
+		return current
+	}
+
+	var accuracy, wpm float64
+	for _, r := range recent {
+		accuracy += r.Accuracy
+		wpm += r.WPM
+	}
+
+	accuracy /= float64(len(recent))
+	wpm /= float64(len(recent))
+
+	if accuracy >= raiseAccuracy && latest.WPM >= wpm*0.9 {
+		return min(current+1, len(levels))
+	}
+
+	return current
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// Number of rows each large character is drawn with.
+const glyphHeight = 5
+
+// Fewest rows the terminal needs for the word being typed to be drawn in
+// large letters, along with the rest of the test.
+const bigTextMinHeight = 20
+
+// Large versions of the digits, letters, and common punctuation, drawn with
+// block characters.
+var glyphs = map[rune][glyphHeight]string{
+	'0':  {"███", "█ █", "█ █", "█ █", "███"},
+	'1':  {" █ ", "██ ", " █ ", " █ ", "███"},
+	'2':  {"███", "  █", "███", "█  ", "███"},
+	'3':  {"███", "  █", " ██", "  █", "███"},
+	'4':  {"█ █", "█ █", "███", "  █", "  █"},
+	'5':  {"███", "█  ", "███", "  █", "███"},
+	'6':  {"███", "█  ", "███", "█ █", "███"},
+	'7':  {"███", "  █", "  █", "  █", "  █"},
+	'8':  {"███", "█ █", "███", "█ █", "███"},
+	'9':  {"███", "█ █", "███", "  █", "███"},
+	'A':  {"███", "█ █", "███", "█ █", "█ █"},
+	'B':  {"██ ", "█ █", "██ ", "█ █", "██ "},
+	'C':  {"███", "█  ", "█  ", "█  ", "███"},
+	'D':  {"██ ", "█ █", "█ █", "█ █", "██ "},
+	'E':  {"███", "█  ", "██ ", "█  ", "███"},
+	'F':  {"███", "█  ", "██ ", "█  ", "█  "},
+	'G':  {"███", "█  ", "█ █", "█ █", "███"},
+	'H':  {"█ █", "█ █", "███", "█ █", "█ █"},
+	'I':  {"███", " █ ", " █ ", " █ ", "███"},
+	'J':  {"  █", "  █", "  █", "█ █", "███"},
+	'K':  {"█ █", "█ █", "██ ", "█ █", "█ █"},
+	'L':  {"█  ", "█  ", "█  ", "█  ", "███"},
+	'M':  {"█   █", "██ ██", "█ █ █", "█   █", "█   █"},
+	'N':  {"█  █", "██ █", "█ ██", "█  █", "█  █"},
+	'O':  {"███", "█ █", "█ █", "█ █", "███"},
+	'P':  {"███", "█ █", "███", "█  ", "█  "},
+	'Q':  {"███", "█ █", "█ █", "███", "  █"},
+	'R':  {"███", "█ █", "██ ", "█ █", "█ █"},
+	'S':  {"███", "█  ", "███", "  █", "███"},
+	'T':  {"███", " █ ", " █ ", " █ ", " █ "},
+	'U':  {"█ █", "█ █", "█ █", "█ █", "███"},
+	'V':  {"█ █", "█ █", "█ █", "█ █", " █ "},
+	'W':  {"█   █", "█   █", "█ █ █", "██ ██", "█   █"},
+	'X':  {"█ █", "█ █", " █ ", "█ █", "█ █"},
+	'Y':  {"█ █", "█ █", " █ ", " █ ", " █ "},
+	'Z':  {"███", "  █", " █ ", "█  ", "███"},
+	'a':  {"   ", " ██", "█ █", "█ █", " ██"},
+	'b':  {"█  ", "██ ", "█ █", "█ █", "██ "},
+	'c':  {"   ", " ██", "█  ", "█  ", " ██"},
+	'd':  {"  █", " ██", "█ █", "█ █", " ██"},
+	'e':  {"   ", "███", "█ █", "██ ", " ██"},
+	'f':  {" ██", "█  ", "██ ", "█  ", "█  "},
+	'g':  {"   ", " ██", "█ █", " ██", "██ "},
+	'h':  {"█  ", "██ ", "█ █", "█ █", "█ █"},
+	'i':  {" █ ", "   ", " █ ", " █ ", " █ "},
+	'j':  {"  █", "   ", "  █", "  █", "██ "},
+	'k':  {"█  ", "█ █", "██ ", "█ █", "█ █"},
+	'l':  {"██ ", " █ ", " █ ", " █ ", " ██"},
+	'm':  {"     ", "████ ", "█ █ █", "█ █ █", "█ █ █"},
+	'n':  {"   ", "██ ", "█ █", "█ █", "█ █"},
+	'o':  {"   ", " █ ", "█ █", "█ █", " █ "},
+	'p':  {"   ", "██ ", "█ █", "██ ", "█  "},
+	'q':  {"   ", " ██", "█ █", " ██", "  █"},
+	'r':  {"   ", "█ █", "██ ", "█  ", "█  "},
+	's':  {"   ", " ██", "█  ", "  █", "██ "},
+	't':  {" █ ", "███", " █ ", " █ ", " ██"},
+	'u':  {"   ", "█ █", "█ █", "█ █", " ██"},
+	'v':  {"   ", "█ █", "█ █", "█ █", " █ "},
+	'w':  {"     ", "█   █", "█ █ █", "█ █ █", " █ █ "},
+	'x':  {"   ", "█ █", " █ ", " █ ", "█ █"},
+	'y':  {"   ", "█ █", "█ █", " ██", "██ "},
+	'z':  {"   ", "███", " ██", "█  ", "███"},
+	'.':  {"   ", "   ", "   ", "   ", " █ "},
+	',':  {"   ", "   ", "   ", " █ ", "█  "},
+	'\'': {" █ ", " █ ", "   ", "   ", "   "},
+	'"':  {"█ █", "█ █", "   ", "   ", "   "},
+	'!':  {" █ ", " █ ", " █ ", "   ", " █ "},
+	'?':  {"███", "  █", " ██", "   ", " █ "},
+	'-':  {"   ", "   ", "███", "   ", "   "},
+	':':  {"   ", " █ ", "   ", " █ ", "   "},
+	';':  {"   ", " █ ", "   ", " █ ", "█  "},
+	'(':  {" █", "█ ", "█ ", "█ ", " █"},
+	')':  {"█ ", " █", " █", " █", "█ "},
+	'/':  {"  █", "  █", " █ ", "█  ", "█  "},
+}
+
+// Draw a number in large digits, rounded to the nearest whole number.
+func bigNumber(n float64) string {
+	digits := fmt.Sprintf("%.0f", n)
+
+	rows := make([]string, glyphHeight)
+	for i := range rows {
+		var glyphRow []string
+		for _, d := range digits {
+			glyphRow = append(glyphRow, glyphs[d][i])
+		}
+
+		rows[i] = strings.Join(glyphRow, " ")
+	}
+
+	return strings.Join(rows, "\n")
+}
+
+// Get the large version of a character. Characters without one are drawn at
+// full width in the middle row, so they still line up with the rest.
+func glyph(c string) [glyphHeight]string {
+	r := []rune(c)
+	if len(r) == 1 {
+		if g, ok := glyphs[r[0]]; ok {
+			return g
+		}
+
+		c = string(fullWidth(r[0]))
+	}
+
+	var g [glyphHeight]string
+	blank := strings.Repeat(" ", uniseg.StringWidth(c))
+	for i := range g {
+		g[i] = blank
+	}
+
+	g[glyphHeight/2] = c
+	return g
+}
+
+// Render the word the user is typing in large letters, for reading from
+// across the room. A terminal too small to fit them gets the word in
+// full-width letters on a single line instead.
+func (m Model) bigWordView() string {
+	cells := m.test.Cells()
+	start, end := m.activeWord()
+
+	rows := make([]string, glyphHeight)
+	width := 0
+	for i := start; i < end; i++ {
+		g := glyph(cells[i].Expected)
+		for row := range rows {
+			if i > start {
+				rows[row] += " "
+			}
+
+			rows[row] += m.renderCell(i, g[row])
+		}
+
+		width += uniseg.StringWidth(g[0]) + 1
+	}
+
+	if width-1 > m.promptWidth() || (m.height > 0 && m.height < bigTextMinHeight) {
+		return m.kidsBanner()
+	}
+
+	return strings.Join(rows, "\n")
+}
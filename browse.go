@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Number of results shown, and fetched from the store, at a time.
+const pageSize = 20
+
+// Represents the screen for browsing every saved result. Only the page on
+// screen is kept in memory, so long histories open as fast as short ones.
+type historyPage struct {
+	results  []Result // Results on the current page, newest first
+	offset   int      // Number of newer results before the page
+	selected int      // Index of the highlighted result on the page
+	err      error    // Problem fetching the page
+}
+
+// Create a model that shows the newest page of results.
+func historyModel(settings Settings, home string, dir string, store Store, name string) Model {
+	m := Model{
+		settings: settings,
+		home:     home,
+		dir:      dir,
+		store:    store,
+		user:     name,
+		view:     HISTORY,
+	}
+
+	m.history.results, m.history.err = store.Page(0, pageSize)
+	return m
+}
+
+// Fetch the page starting offset results from the newest. Pages past the end
+// of the history are ignored.
+func (m *Model) fetchPage(offset int) bool {
+	if offset < 0 {
+		return false
+	}
+
+	results, err := m.store.Page(offset, pageSize)
+	if err != nil {
+		m.history.err = err
+		return false
+	}
+
+	if len(results) == 0 {
+		return false
+	}
+
+	m.history.results = results
+	m.history.offset = offset
+	return true
+}
+
+// Manages the history screen. Moving past either end of the page fetches the
+// next or previous one.
+func (m Model) updateHistory(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		h := &m.history
+
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+
+		case "down", "j":
+			if h.selected < len(h.results)-1 {
+				h.selected++
+			} else if m.fetchPage(h.offset + pageSize) {
+				h.selected = 0
+			}
+
+		case "up", "k":
+			if h.selected > 0 {
+				h.selected--
+			} else if m.fetchPage(h.offset - pageSize) {
+				h.selected = len(h.results) - 1
+			}
+
+		case "pgdown", "right", "l":
+			if m.fetchPage(h.offset + pageSize) {
+				h.selected = 0
+			}
+
+		case "pgup", "left", "h":
+			if m.fetchPage(h.offset - pageSize) {
+				h.selected = 0
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Render the current page of results.
+func (m Model) historyView() string {
+	h := m.history
+	s := "History\n\n"
+
+	if h.err != nil {
+		return s + fmt.Sprintf("Failed to get history: %v\n", h.err)
+	}
+
+	if len(h.results) == 0 {
+		return s + "No results yet. Finish a test to see it here!\n"
+	}
+
+	for i, r := range h.results {
+		line := fmt.Sprintf("%4d  %v", h.offset+i+1, r.summary())
+		if r.Incomplete {
+			line += " (incomplete)"
+		}
+
+		if r.Failed {
+			line += " (failed)"
+		}
+
+		if i == h.selected && m.settings.plain {
+			s += line + " <"
+		} else if i == h.selected {
+			s += m.settings.theme.cursor.Render(line)
+		} else {
+			s += line
+		}
+
+		s += "\n"
+	}
+
+	s += "\n↑/↓ to scroll, ←/→ for the previous or next page, q to quit"
+	return s
+}
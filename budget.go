@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Represents how much time the user means to spend practicing, e.g.
+//
+//	[budget]
+//	daily = 20
+type Budget struct {
+	Daily  int `toml:"daily"`  // Minutes to practice every day (0 for no goal)
+	Weekly int `toml:"weekly"` // Minutes to practice every week, starting Monday (0 for no goal)
+}
+
+// Report whether any goal is set.
+func (b Budget) enabled() bool {
+	return b.Daily > 0 || b.Weekly > 0
+}
+
+// Make sure the goals make sense.
+func (b Budget) validate() error {
+	if b.Daily < 0 || b.Weekly < 0 {
+		return errors.New("practice budget can't be negative")
+	}
+
+	return nil
+}
+
+// Represents the time spent practicing so far today and this week.
+type ledger struct {
+	today time.Duration
+	week  time.Duration
+}
+
+// Get the start of the local day t falls on.
+func startOfDay(t time.Time) time.Time {
+	t = t.Local()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Get the start of the Monday of the week t falls in.
+func startOfWeek(t time.Time) time.Time {
+	day := startOfDay(t)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// Add up the time spent typing in every result since the start of the day
+// and week of now. Tests ended early still count, since the time was spent.
+func newLedger(results []Result, now time.Time) ledger {
+	var l ledger
+	day, week := startOfDay(now), startOfWeek(now)
+
+	for _, r := range results {
+		elapsed := time.Duration(r.Elapsed * float64(time.Second))
+		if !r.Time.Before(week) {
+			l.week += elapsed
+		}
+
+		if !r.Time.Before(day) {
+			l.today += elapsed
+		}
+	}
+
+	return l
+}
+
+// Render how much of the budget is left, counting the test being taken, or
+// nothing without a budget.
+func (m Model) budgetView() string {
+	b := m.settings.budget
+	if !b.enabled() {
+		return ""
+	}
+
+	var elapsed time.Duration
+	if m.test != nil && m.view == PROMPT {
+		elapsed = m.test.Elapsed()
+	}
+
+	left := func(spent time.Duration, minutes int) string {
+		remaining := time.Duration(minutes)*time.Minute - spent
+		if remaining <= 0 {
+			return "done"
+		}
+
+		return fmt.Sprintf("%v min left", int(remaining.Minutes()+0.5))
+	}
+
+	s := "Practice:"
+	if b.Daily > 0 {
+		s += fmt.Sprintf(" %v today", left(m.ledger.today+elapsed, b.Daily))
+	}
+
+	if b.Daily > 0 && b.Weekly > 0 {
+		s += ","
+	}
+
+	if b.Weekly > 0 {
+		s += fmt.Sprintf(" %v this week", left(m.ledger.week+elapsed, b.Weekly))
+	}
+
+	return s
+}
+
+// Prints the time spent practicing on each of the last seven days, and how
+// it compares to the budget in the config file.
+func week(args []string, cfg Config) int {
+	flags := flag.NewFlagSet("week", flag.ContinueOnError)
+	name := flags.String("user", "", "print the practice of this user instead of the shared one")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	store, code := openUserStore(cfg, *name)
+	if store == nil {
+		return code
+	}
+
+	results, err := store.Load()
+	if err != nil {
+		fmt.Printf("failed to get history: %v\n", err)
+		return 1
+	}
+
+	b := cfg.Budget
+	today := startOfDay(time.Now())
+	met := 0
+	var total time.Duration
+
+	for i := 6; i >= 0; i-- {
+		start := today.AddDate(0, 0, -i)
+		end := start.AddDate(0, 0, 1)
+
+		var spent time.Duration
+		tests := 0
+		for _, r := range results {
+			if !r.Time.Before(start) && r.Time.Before(end) {
+				spent += time.Duration(r.Elapsed * float64(time.Second))
+				tests++
+			}
+		}
+
+		total += spent
+		line := fmt.Sprintf("%v  %3v tests  %3v min", start.Format("Mon 2006-01-02"), tests, int(spent.Minutes()+0.5))
+		if b.Daily > 0 && spent >= time.Duration(b.Daily)*time.Minute {
+			line += "  goal met"
+			met++
+		}
+
+		fmt.Println(line)
+	}
+
+	fmt.Println()
+	fmt.Printf("Total: %v min over the last 7 days.\n", int(total.Minutes()+0.5))
+
+	if b.Daily > 0 {
+		fmt.Printf("Daily goal of %v min met on %v of 7 days.\n", b.Daily, met)
+	}
+
+	if b.Weekly > 0 {
+		l := newLedger(results, time.Now())
+		fmt.Printf("This week (since Monday): %v of %v min.\n", int(l.week.Minutes()+0.5), b.Weekly)
+	}
+
+	return 0
+}
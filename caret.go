@@ -0,0 +1,59 @@
+package main
+
+import "fmt"
+
+// Represents how the character the user is on is marked.
+type CaretStyle int16
+
+const (
+	BLOCK     CaretStyle = iota // Character drawn in the cursor's colors
+	UNDERLINE                   // Character underlined
+	BAR                         // Bar drawn before the character
+)
+
+// Every caret style, in the order they are listed to the user.
+var caretStyles = []CaretStyle{BLOCK, UNDERLINE, BAR}
+
+// Get the name of a caret style, as used on the command line and in the
+// config file.
+func (c CaretStyle) String() string {
+	switch c {
+	case UNDERLINE:
+		return "underline"
+	case BAR:
+		return "bar"
+	default:
+		return "block"
+	}
+}
+
+// Get a caret style from its name.
+func parseCaretStyle(name string) (CaretStyle, error) {
+	for _, c := range caretStyles {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+
+	return BLOCK, fmt.Errorf("unknown caret style: %v", name)
+}
+
+// Allows the caret style to be read from the config file by name.
+func (c *CaretStyle) UnmarshalText(text []byte) error {
+	style, err := parseCaretStyle(string(text))
+	*c = style
+	return err
+}
+
+// Render the character the user is on with the caret.
+func (m Model) caretView(text string) string {
+	theme := m.settings.theme
+	switch m.settings.caret {
+	case UNDERLINE:
+		return theme.prompt.Underline(true).Render(text)
+	case BAR:
+		return theme.cursor.Render("|") + theme.prompt.Render(text)
+	default:
+		return theme.cursor.Render(text)
+	}
+}
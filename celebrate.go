@@ -0,0 +1,91 @@
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Number of frames in the personal best animation.
+const celebrationFrames = 30
+
+// Time between frames of the personal best animation.
+const frameInterval = 60 * time.Millisecond
+
+// Size of the area the confetti falls through.
+const (
+	confettiWidth  = 40
+	confettiHeight = 6
+	confettiCount  = 50
+)
+
+// Shapes and colors a piece of confetti can have.
+var (
+	confettiShapes = []rune{'*', '+', 'o', '.', '•', '✦'}
+	confettiColors = []lipgloss.Color{"#e2b714", "#ff5555", "#50fa7b", "#8be9fd", "#bd93f9", "#ff79c6"}
+)
+
+type frameMsg time.Time
+
+// Represents a single piece of confetti.
+type particle struct {
+	x     int            // Column the particle falls down
+	y     int            // Row the particle starts on, above the top when negative
+	speed int            // Rows moved per frame
+	shape rune           // Character used to draw the particle
+	color lipgloss.Color // Color used to draw the particle
+}
+
+// Frames are used to animate the stats screen, much faster than ticks.
+func nextFrame() tea.Cmd {
+	return tea.Tick(frameInterval, func(t time.Time) tea.Msg {
+		return frameMsg(t)
+	})
+}
+
+// Scatter confetti above the top of the screen, so it rains down over the
+// course of the animation.
+func newConfetti() []particle {
+	confetti := make([]particle, confettiCount)
+	for i := range confetti {
+		confetti[i] = particle{
+			x:     rand.Intn(confettiWidth),
+			y:     -rand.Intn(celebrationFrames),
+			speed: 1 + rand.Intn(2),
+			shape: confettiShapes[rand.Intn(len(confettiShapes))],
+			color: confettiColors[rand.Intn(len(confettiColors))],
+		}
+	}
+
+	return confetti
+}
+
+// Report whether the personal best animation is still playing.
+func (m Model) celebrating() bool {
+	return m.confetti != nil && m.frame < celebrationFrames
+}
+
+// Render the current frame of the personal best animation.
+func (m Model) confettiView() string {
+	grid := make([][]string, confettiHeight)
+	for y := range grid {
+		grid[y] = strings.Split(strings.Repeat(" ", confettiWidth), "")
+	}
+
+	for _, p := range m.confetti {
+		y := p.y + m.frame*p.speed
+		if y >= 0 && y < confettiHeight {
+			grid[y][p.x] = lipgloss.NewStyle().Foreground(p.color).Render(string(p.shape))
+		}
+	}
+
+	lines := make([]string, confettiHeight)
+	for y, row := range grid {
+		lines[y] = strings.Join(row, "")
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
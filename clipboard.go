@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"unicode"
+)
+
+// Commands that print the contents of the clipboard on each operating system,
+// in the order they are tried.
+var clipboardCommands = map[string][][]string{
+	"darwin":  {{"pbpaste"}},
+	"windows": {{"powershell", "-NoProfile", "-Command", "Get-Clipboard"}},
+	"linux": {
+		{"wl-paste", "--no-newline"},
+		{"xclip", "-selection", "clipboard", "-o"},
+		{"xsel", "--clipboard", "--output"},
+	},
+}
+
+// Get the text on the system clipboard, using the first clipboard tool that is
+// installed.
+func readClipboard() (string, error) {
+	for _, command := range clipboardCommands[runtime.GOOS] {
+		if _, err := exec.LookPath(command[0]); err != nil {
+			continue
+		}
+
+		out, err := exec.Command(command[0], command[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to read clipboard: %v", err)
+		}
+
+		return string(out), nil
+	}
+
+	return "", errors.New("failed to read clipboard: no clipboard tool found (install wl-clipboard, xclip, or xsel)")
+}
+
+// Make text safe to use as a prompt: whitespace such as tabs and newlines
+// becomes a space, and any other control characters are removed.
+func sanitize(text string) string {
+	return strings.Map(func(c rune) rune {
+		switch {
+		case unicode.IsSpace(c):
+			return ' '
+		case unicode.IsControl(c) || !unicode.IsPrint(c):
+			return -1
+		default:
+			return c
+		}
+	}, text)
+}
+
+// Replaces typographic characters with the ones found on a keyboard.
+var typographic = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'", "′", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`, "″", `"`, "«", `"`, "»", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "―", "-", "−", "-",
+	"…", "...",
+)
+
+// Replace curly quotes, dashes, and other characters most keyboards can't
+// type with their plain equivalents.
+func normalize(text string) string {
+	return typographic.Replace(text)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Language saved with results of code mode.
+const codeLanguage = "code"
+
+// Programming language of the snippets typed in code mode, unless another one
+// is picked.
+const snippetsDefault = "go"
+
+// Snippets of code built into the program, typed in code mode when no code is
+// given, one list per programming language.
+//
+//go:embed snippets/*.json
+var builtinSnippets embed.FS
+
+// Spaces a tab is replaced with in code.
+const tabWidth = 4
+
+// Clean up code to type: like sanitize, but line breaks are kept, tabs become
+// spaces, and spaces at the end of lines and blank lines around the code are
+// dropped, since there's nothing to see of them.
+func sanitizeCode(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\t", strings.Repeat(" ", tabWidth))
+
+	text = strings.Map(func(c rune) rune {
+		switch {
+		case c == '\n':
+			return c
+		case unicode.IsSpace(c):
+			return ' '
+		case unicode.IsControl(c) || !unicode.IsPrint(c):
+			return -1
+		default:
+			return c
+		}
+	}, text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// Get the programming languages there are snippets for, sorted.
+func snippetLanguages() []string {
+	entries, err := builtinSnippets.ReadDir("snippets")
+	if err != nil {
+		return nil
+	}
+
+	var languages []string
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	return languages
+}
+
+// Make sure there are snippets for a programming language.
+func checkSnippets(language string) error {
+	for _, l := range snippetLanguages() {
+		if l == language {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown snippet language: %v (expected one of: %v)", language, strings.Join(snippetLanguages(), ", "))
+}
+
+// Pick a random built-in snippet of code in a programming language.
+func randomSnippet(language string) (string, error) {
+	if err := checkSnippets(language); err != nil {
+		return "", err
+	}
+
+	data, err := builtinSnippets.ReadFile("snippets/" + language + ".json")
+	if err != nil {
+		return "", fmt.Errorf("failed to read snippets: %v", err)
+	}
+
+	var snippets []string
+	if err := json.Unmarshal(data, &snippets); err != nil {
+		return "", fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	if len(snippets) == 0 {
+		return "", errors.New("no snippets found")
+	}
+
+	return sanitizeCode(snippets[rand.Intn(len(snippets))]), nil
+}
+
+// Type a line break, and the indentation of the next line with it, the way an
+// editor would, so only the code itself has to be typed.
+func (m *Model) typeLine() {
+	if _, ok := m.test.Type('\n'); !ok {
+		return
+	}
+
+	cells := m.test.Cells()
+	if !cells[m.test.Cursor()-1].Correct() {
+		return
+	}
+
+	for m.test.State() == engine.TYPING && cells[m.test.Cursor()].Expected == " " {
+		m.test.Type(' ')
+	}
+}
+
+// Type the indentation up to the next tab stop, the way an editor would with
+// tabs expanded. Reports false if the cursor isn't in the indentation of a
+// line, leaving the test as it was.
+func (m *Model) typeTab() bool {
+	cells := m.test.Cells()
+	cursor := m.test.Cursor()
+	if cursor >= len(cells) || cells[cursor].Expected != " " {
+		return false
+	}
+
+	for i := cursor - 1; i >= 0 && cells[i].Expected != "\n"; i-- {
+		if cells[i].Expected != " " {
+			return false
+		}
+	}
+
+	for range tabWidth {
+		if m.test.State() == engine.DONE || cells[m.test.Cursor()].Expected != " " {
+			break
+		}
+
+		m.test.Type(' ')
+	}
+
+	return true
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Correct characters in a row needed to raise the multiplier by one.
+const comboStep = 10
+
+// Highest multiplier a combo can reach.
+const maxMultiplier = 8
+
+// Keeps the arcade score: every correct character scores points times the
+// multiplier, which grows with the number of correct characters in a row and
+// drops back to 1 on a mistake.
+type combo struct {
+	current int // Correct characters in a row
+	best    int // Longest run of correct characters
+	points  int // Total score
+}
+
+// Update the combo with every scored keystroke.
+func (c *combo) observe(ev engine.Event) {
+	key, ok := ev.(engine.KeystrokeScored)
+	if !ok {
+		return
+	}
+
+	if !key.Correct {
+		c.current = 0
+		return
+	}
+
+	c.current++
+	c.best = max(c.best, c.current)
+	c.points += c.multiplier()
+}
+
+// Get the multiplier the current combo earns.
+func (c *combo) multiplier() int {
+	return min(1+c.current/comboStep, maxMultiplier)
+}
+
+// Render the combo for the header while typing.
+func (c *combo) view() string {
+	return fmt.Sprintf("combo %v x%v | %v pts", c.current, c.multiplier(), c.points)
+}
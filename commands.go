@@ -0,0 +1,606 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Every command, and what it does, as listed in the usage message.
+var commands = []struct {
+	name  string
+	usage string
+}{
+	{"run", "start a test (the default when no command is given)"},
+	{"drill", "type lines of a file as code, printing the result (--file, --lines)"},
+	{"last", "print the most recent results (-n, --json, --user)"},
+	{"stats", "print a summary of every result (--json, --user)"},
+	{"week", "print the time spent practicing each of the last 7 days (--user)"},
+	{"history prune", "delete old results (--keep, --months, --summarize)"},
+	{"history rescore", "score every result again with the current formulas (--dry-run)"},
+	{"words list", "print every word list that can be picked with --language"},
+	{"words install", "copy a word list file or language folder into the words directory (--name)"},
+	{"words remove", "delete a word list from the words directory"},
+	{"generate", "print a prompt without starting a test (--mode, --seed, ...)"},
+	{"config", "print where the config file is, and the defaults it sets"},
+	{"paths", "print where every file is kept"},
+}
+
+// Print how to use the program: its commands, then its flags.
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %v [flags] [command] [command flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-14v %v\n", c.name, c.usage)
+	}
+
+	fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+// Runs a command given on the command line instead of starting the
+// application. Returns the exit code.
+func runCommand(args []string, cfg Config) int {
+	switch {
+	case args[0] == "last":
+		return last(args[1:], cfg)
+	case args[0] == "week":
+		return week(args[1:], cfg)
+	case args[0] == "paths":
+		return paths(cfg)
+	case args[0] == "stats":
+		return stats(args[1:], cfg)
+	case args[0] == "config":
+		return showConfig(cfg)
+	case strings.Join(args[:min(len(args), 2)], " ") == "words list":
+		return listWords()
+	case strings.Join(args[:min(len(args), 2)], " ") == "words install":
+		return installWordsCommand(args[2:])
+	case strings.Join(args[:min(len(args), 2)], " ") == "words remove":
+		return removeWordsCommand(args[2:])
+	case args[0] == "generate":
+		return generate(args[1:], cfg)
+	case strings.Join(args[:min(len(args), 2)], " ") == "history prune":
+		return prune(args[2:], cfg)
+	case strings.Join(args[:min(len(args), 2)], " ") == "history rescore":
+		return rescore(args[2:], cfg)
+	default:
+		fmt.Printf("unknown command: %v\n", strings.Join(args, " "))
+		return 2
+	}
+}
+
+// Deletes old results, following the limits in the config file unless they
+// are overridden by flags.
+func prune(args []string, cfg Config) int {
+	rt := cfg.History
+
+	flags := flag.NewFlagSet("history prune", flag.ContinueOnError)
+	flags.IntVar(&rt.KeepResults, "keep", rt.KeepResults, "number of most recent results to keep")
+	flags.IntVar(&rt.KeepMonths, "months", rt.KeepMonths, "delete results older than this many months")
+	flags.BoolVar(&rt.Summarize, "summarize", rt.Summarize, "save daily summaries of deleted results")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := rt.validate(); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if !rt.enabled() {
+		fmt.Println("nothing to prune: set keep_results or keep_months under [history] in the config file, or pass --keep or --months")
+		return 2
+	}
+
+	// Everyone's history follows the same limits on a shared machine.
+	dirs, err := historyDirs()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	total := 0
+	for _, dir := range dirs {
+		store, err := openStore(cfg.Storage, dir)
+		if err != nil {
+			fmt.Printf("failed to open history in %v: %v\n", dir, err)
+			return 1
+		}
+
+		n, err := pruneHistory(store, dir, rt, time.Now())
+		if err != nil {
+			fmt.Printf("failed to prune history in %v: %v\n", dir, err)
+			return 1
+		}
+
+		total += n
+	}
+
+	fmt.Printf("Deleted %v results.\n", total)
+	return 0
+}
+
+// Scores every result again with the current formulas, so results saved
+// before they changed can be compared with new ones.
+func rescore(args []string, cfg Config) int {
+	flags := flag.NewFlagSet("history rescore", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "only print how many results would change")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	dirs, err := historyDirs()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	total := 0
+	for _, dir := range dirs {
+		store, err := openStore(cfg.Storage, dir)
+		if err != nil {
+			fmt.Printf("failed to open history in %v: %v\n", dir, err)
+			return 1
+		}
+
+		if *dryRun {
+			store = dryRunStore{store}
+		}
+
+		n, err := rescoreHistory(store)
+		if err != nil {
+			fmt.Printf("failed to rescore history in %v: %v\n", dir, err)
+			return 1
+		}
+
+		total += n
+	}
+
+	if *dryRun {
+		fmt.Printf("%v results would be rescored.\n", total)
+	} else {
+		fmt.Printf("Rescored %v results.\n", total)
+	}
+
+	return 0
+}
+
+// Prints the prompt a test would be given, without starting one. With the
+// same seed and word list, the same prompt is printed every time.
+func generate(args []string, cfg Config) int {
+	language := languageDefault
+	if len(cfg.Languages) > 0 {
+		language = strings.Join(cfg.Languages, languageSeparator)
+	}
+
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	modeName := flags.String("mode", cfg.Mode.String(), "kind of test to make the prompt for: time, words, stopwatch, or kids")
+	flags.StringVar(&language, "language", language, "word list to pick words from; join several with + to mix them")
+	count := flags.Int("words", cfg.Words, "number of words in the prompt (default depends on the mode)")
+	tier := flags.Int("tier", cfg.Tier, "only pick from this many of the most common words (0 for all)")
+	punctuation := flags.Bool("punctuation", cfg.Punctuation, "follow some words with punctuation")
+	numbers := flags.Bool("numbers", cfg.Numbers, "replace some words with random numbers")
+	seed := flags.Int64("seed", 0, "seed for the random choices, to get the same prompt again (default random, printed to stderr)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	mode, err := parseMode(*modeName)
+	if err != nil {
+		fmt.Println(err)
+		return 2
+	}
+
+	if !slices.Contains([]Mode{TIME, WORD_COUNT, STOPWATCH, KIDS}, mode) {
+		fmt.Printf("%v mode doesn't make prompts from a word list\n", mode)
+		return 2
+	}
+
+	if *count < 0 || *count > maxWords {
+		fmt.Printf("invalid word count: %v (expected 0 to %v words)\n", *count, maxWords)
+		return 2
+	}
+
+	if *tier < 0 {
+		fmt.Printf("invalid tier: %v (expected 0 or more words)\n", *tier)
+		return 2
+	}
+
+	words, err := loadWords(Settings{language: language})
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if *seed == 0 {
+		*seed = rand.Int63()
+		fmt.Fprintf(os.Stderr, "seed: %v\n", *seed)
+	}
+
+	pc := promptConfig{words: promptWordsDefault, tier: *tier, rng: rand.New(rand.NewSource(*seed))}
+	if *punctuation {
+		pc.punctuation = punctuationDefault
+	}
+
+	if *numbers {
+		pc.numbers = numbersDefault
+	}
+
+	switch mode {
+	case KIDS:
+		words = kidsWords(words)
+		pc.words = kidsWordsDefault
+	case WORD_COUNT:
+		pc.words = wordCountDefault
+	}
+
+	if *count > 0 {
+		pc.words = *count
+	}
+
+	if len(words) == 0 {
+		fmt.Println(errNoWords)
+		return 1
+	}
+
+	fmt.Println(generatePrompt(words, pc))
+	return 0
+}
+
+// Get the directory of the shared history, followed by every user's.
+func historyDirs() ([]string, error) {
+	home, err := dataDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{home}
+	users, err := loadUsers(home)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, u := range users {
+		dirs = append(dirs, userDir(home, u.Name))
+	}
+
+	return dirs, nil
+}
+
+// Open the history of the user with the given name, or the shared one if it's
+// empty. Returns nil and the exit code once the problem is printed, if it
+// can't be opened.
+func openUserStore(cfg Config, name string) (Store, int) {
+	home, err := dataDir()
+	if err != nil {
+		fmt.Println(err)
+		return nil, 1
+	}
+
+	dir := home
+	if name != "" {
+		if err := validateName(name); err != nil {
+			fmt.Println(err)
+			return nil, 2
+		}
+
+		dir = userDir(home, name)
+	}
+
+	store, err := openStore(cfg.Storage, dir)
+	if err != nil {
+		fmt.Printf("failed to open history in %v: %v\n", dir, err)
+		return nil, 1
+	}
+
+	return store, 0
+}
+
+// Prints the most recent results without opening the application, oldest
+// first.
+func last(args []string, cfg Config) int {
+	flags := flag.NewFlagSet("last", flag.ContinueOnError)
+	n := flags.Int("n", 1, "number of results to print")
+	asJSON := flags.Bool("json", false, "print results as JSON, one per line")
+	name := flags.String("user", "", "print the results of this user instead of the shared ones")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if *n < 1 {
+		fmt.Println("n must be at least 1")
+		return 2
+	}
+
+	store, code := openUserStore(cfg, *name)
+	if store == nil {
+		return code
+	}
+
+	results, err := store.Page(0, *n)
+	if err != nil {
+		fmt.Printf("failed to get history: %v\n", err)
+		return 1
+	}
+
+	if len(results) == 0 {
+		fmt.Println("no results yet")
+		return 1
+	}
+
+	slices.Reverse(results)
+
+	for _, r := range results {
+		if *asJSON {
+			data, err := json.Marshal(summaryReport(r))
+			if err != nil {
+				fmt.Printf("failed to encode json: %v\n", err)
+				return 1
+			}
+
+			fmt.Println(string(data))
+		} else {
+			fmt.Println(r.summary())
+		}
+	}
+
+	return 0
+}
+
+// Describe a result on a single line.
+func (r Result) summary() string {
+	k := r.key()
+
+	kind := k.mode
+	if r.Duration > 0 {
+		kind += fmt.Sprintf(" %vs", r.Duration)
+	} else if k.words > 0 {
+		kind += fmt.Sprintf(" %v", k.words)
+	}
+
+	return fmt.Sprintf(
+		"%v  %v, %v  %.2f WPM (raw %.2f, %.2f%% accuracy)",
+		r.Time.Local().Format(time.DateTime),
+		kind,
+		k.language,
+		r.WPM,
+		r.Raw,
+		r.Accuracy,
+	)
+}
+
+// Represents every result summed up, as printed by the stats command.
+type Stats struct {
+	Tests    int     `json:"tests"`    // Number of tests finished
+	Minutes  float64 `json:"minutes"`  // Time spent typing
+	WPM      float64 `json:"wpm"`      // Average words per minute
+	Best     float64 `json:"best"`     // Best words per minute
+	Accuracy float64 `json:"accuracy"` // Average percentage of correct keystrokes
+	Today    int     `json:"today"`    // Tests finished today
+	Streak   int     `json:"streak"`   // Days in a row with at least one test, up to today
+}
+
+// Sum up results as of now. Incomplete and failed tests don't count.
+func newStats(results []Result, now time.Time) Stats {
+	var s Stats
+	for _, r := range results {
+		if r.Incomplete || r.Failed {
+			continue
+		}
+
+		s.Tests++
+		s.Minutes += r.Elapsed / 60
+		s.WPM += r.WPM
+		s.Best = max(s.Best, r.WPM)
+		s.Accuracy += r.Accuracy
+	}
+
+	if s.Tests > 0 {
+		s.WPM /= float64(s.Tests)
+		s.Accuracy /= float64(s.Tests)
+	}
+
+	d := newDashboard(results, now)
+	s.Today = d.today
+	s.Streak = d.streak
+	return s
+}
+
+// Prints a summary of every result without opening the application.
+func stats(args []string, cfg Config) int {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print the summary as JSON")
+	name := flags.String("user", "", "sum up the results of this user instead of the shared ones")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	store, code := openUserStore(cfg, *name)
+	if store == nil {
+		return code
+	}
+
+	results, err := store.Load()
+	if err != nil {
+		fmt.Printf("failed to get history: %v\n", err)
+		return 1
+	}
+
+	s := newStats(results, time.Now())
+	if *asJSON {
+		data, err := json.Marshal(s)
+		if err != nil {
+			fmt.Printf("failed to encode json: %v\n", err)
+			return 1
+		}
+
+		fmt.Println(string(data))
+		return 0
+	}
+
+	if s.Tests == 0 {
+		fmt.Println("no results yet")
+		return 1
+	}
+
+	fmt.Printf("Tests:    %v (%v today)\n", s.Tests, s.Today)
+	fmt.Printf("Time:     %v min\n", int(s.Minutes+0.5))
+	fmt.Printf("Speed:    %.2f WPM on average, %.2f best\n", s.WPM, s.Best)
+	fmt.Printf("Accuracy: %.2f%%\n", s.Accuracy)
+	fmt.Printf("Streak:   %v days\n", s.Streak)
+	return 0
+}
+
+// Prints where the config file is, and the defaults it sets for every test.
+// The config file was already checked when the program started, so getting
+// this far means it's valid.
+func showConfig(cfg Config) int {
+	dir, err := configDir()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	name := filepath.Join(dir, configFile)
+	if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("# %v (not created yet, so these are the defaults)\n", name)
+	} else {
+		fmt.Printf("# %v\n", name)
+	}
+
+	languages := cfg.Languages
+	if len(languages) == 0 {
+		languages = []string{languageDefault}
+	}
+
+	theme := cfg.Theme
+	if theme == "" {
+		theme = themeDefault
+	}
+
+	snippets := cfg.Snippets
+	if snippets == "" {
+		snippets = snippetsDefault
+	}
+
+	fmt.Printf("mode = %q\n", cfg.Mode)
+	fmt.Printf("duration = %v\n", cfg.Duration)
+	fmt.Printf("words = %v\n", cfg.Words)
+	fmt.Printf("tier = %v\n", cfg.Tier)
+	fmt.Printf("languages = [%v]\n", quoteAll(languages))
+	fmt.Printf("source = %q\n", cfg.Source)
+	fmt.Printf("punctuation = %v\n", cfg.Punctuation)
+	fmt.Printf("numbers = %v\n", cfg.Numbers)
+	fmt.Printf("adaptive = %v\n", cfg.Adaptive)
+	fmt.Printf("auto = %v\n", cfg.Auto)
+	fmt.Printf("theme = %q\n", theme)
+	fmt.Printf("caret = %q\n", cfg.Caret)
+	fmt.Printf("alert = %q\n", cfg.Alert)
+	fmt.Printf("difficulty = %q\n", cfg.Difficulty)
+	fmt.Printf("forgive_erased = %v\n", cfg.ForgiveErased)
+	fmt.Printf("min_wpm = %v\n", cfg.MinWPM)
+	fmt.Printf("snippets = %q\n", snippets)
+	fmt.Printf("timer = %q\n", cfg.Timer)
+	fmt.Printf("quote_length = %q\n", cfg.QuoteLength)
+	fmt.Printf("on_quit = %q\n", cfg.OnQuit)
+	fmt.Printf("storage = %q\n", cfg.Storage)
+
+	// Tables come last, since every key after one belongs to it.
+	tables := struct {
+		Presets   map[string]Preset   `toml:"presets,omitempty"`
+		Providers map[string][]string `toml:"providers,omitempty"`
+	}{cfg.Presets, cfg.Providers}
+
+	if len(cfg.Presets) > 0 || len(cfg.Providers) > 0 {
+		fmt.Println()
+	}
+
+	encoder := toml.NewEncoder(os.Stdout)
+	encoder.Indent = ""
+	if err := encoder.Encode(tables); err != nil {
+		fmt.Printf("failed to encode config: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// Quote every string and join them with commas, as in a TOML array.
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// Prints every word list that can be picked with --language.
+func listWords() int {
+	languages := installedLanguages()
+	slices.Sort(languages)
+	for _, language := range languages {
+		if isBuiltin(language) && !hasWordsFile(language) {
+			fmt.Printf("%v (built in)\n", language)
+		} else {
+			fmt.Println(languageName(language))
+		}
+	}
+
+	return 0
+}
+
+// Installs a word list, so it can be picked with --language.
+func installWordsCommand(args []string) int {
+	flags := flag.NewFlagSet("words install", flag.ContinueOnError)
+	name := flags.String("name", "", "name to install the list as (default: the name of the file)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() != 1 {
+		fmt.Println("usage: words install [--name NAME] FILE")
+		return 2
+	}
+
+	language, err := installWords(flags.Arg(0), *name)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	fmt.Printf("installed %v; pick it with --language %v\n", languageName(language), language)
+	return 0
+}
+
+// Removes a word list from the words directory.
+func removeWordsCommand(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("usage: words remove NAME")
+		return 2
+	}
+
+	if err := removeWords(args[0]); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if isBuiltin(args[0]) {
+		fmt.Printf("removed %v; the built-in list is used again\n", args[0])
+	} else {
+		fmt.Printf("removed %v\n", args[0])
+	}
+
+	return 0
+}
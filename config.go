@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Name of the file the user can create to change the program's behavior.
+const configFile = "config.toml"
+
+// Matches the colors lipgloss understands: hex codes and ANSI color numbers.
+var colorPattern = regexp.MustCompile(`^(#[0-9a-fA-F]{3}|#[0-9a-fA-F]{6}|[0-9]{1,3})$`)
+
+// Represents the contents of the config file.
+type Config struct {
+	Mode           Mode                `toml:"mode"`            // Kind of test to take
+	Duration       int                 `toml:"duration"`        // Time limit in seconds (0 to ask in the menu)
+	Words          int                 `toml:"words"`           // Number of words in word count mode (0 to ask in the menu)
+	Tier           int                 `toml:"tier"`            // Only pick from this many of the most common words (0 for all)
+	Source         Source              `toml:"source"`          // Where the words of the prompt come from
+	Punctuation    bool                `toml:"punctuation"`     // Follow some words with punctuation
+	Numbers        bool                `toml:"numbers"`         // Replace some words with numbers
+	Adaptive       bool                `toml:"adaptive"`        // Practice weak characters more often
+	Auto           bool                `toml:"auto"`            // Adjust the difficulty based on recent results
+	Theme          string              `toml:"theme"`           // Name of the theme
+	Caret          CaretStyle          `toml:"caret"`           // How the character the user is on is marked
+	Alert          Alert               `toml:"alert"`           // How the user is told the time ran out
+	Difficulty     Difficulty          `toml:"difficulty"`      // How mistakes are dealt with
+	ForgiveErased  bool                `toml:"forgive_erased"`  // Don't count mistakes that were erased against the score
+	MinWPM         int                 `toml:"min_wpm"`         // Fail the test if the speed drops below this
+	Snippets       string              `toml:"snippets"`        // Programming language of the snippets typed in code mode
+	Overlay        string              `toml:"overlay"`         // Address to serve the live state of tests on, for overlays
+	OverlayOrigins []string            `toml:"overlay_origins"` // Web pages allowed to connect to the overlay server
+	FullPrompt     bool                `toml:"full_prompt"`     // Show the whole prompt instead of a few lines at a time
+	BigText        bool                `toml:"big_text"`        // Show the word being typed in large letters
+	Timer          TimerDisplay        `toml:"timer"`           // How the timer is shown during a test
+	Languages      []string            `toml:"languages"`       // Word lists to mix into every prompt
+	Feeds          []string            `toml:"feeds"`           // News feeds used by the RSS source
+	Layout         string              `toml:"layout"`          // Keyboard layout, saved with results
+	Keyboard       string              `toml:"keyboard"`        // Keyboard name, saved with results
+	Learn          string              `toml:"learn"`           // Layout being learned, to show key hints for
+	Keycaps        string              `toml:"keycaps"`         // Layout printed on the keys
+	NoDistractions bool                `toml:"no_distractions"` // Show stats only once the test is over
+	ReducedMotion  bool                `toml:"reduced_motion"`  // Skip animations
+	Remote         bool                `toml:"remote"`          // Tune for a slow connection
+	Plain          bool                `toml:"plain"`           // Draw without colors
+	OnQuit         QuitAction          `toml:"on_quit"`         // What happens when the user quits mid-test
+	ConfirmQuit    bool                `toml:"confirm_quit"`    // Ask before quitting mid-test
+	KeepTypography bool                `toml:"keep_typography"` // Leave curly quotes and dashes in text
+	FilterRepeats  bool                `toml:"filter_repeats"`  // Ignore keys repeated by holding them down
+	Combo          bool                `toml:"combo"`           // Show an arcade score
+	QuoteLength    QuoteLength         `toml:"quote_length"`    // Length of the quotes picked in quote mode
+	Colors         ColorConfig         `toml:"colors"`          // Overrides for the theme's colors
+	Storage        Backend             `toml:"storage"`         // Where results are saved
+	History        Retention           `toml:"history"`         // How much history to keep
+	Budget         Budget              `toml:"budget"`          // How much time the user means to practice
+	Presets        map[string]Preset   `toml:"presets"`         // Named sets of test settings
+	Providers      map[string][]string `toml:"providers"`       // Commands that write drills from the user's weak spots
+}
+
+// Overrides the colors of individual elements, on top of the chosen theme.
+type ColorConfig struct {
+	Prompt  ElementColor `toml:"prompt"`  // Characters that have not been typed yet
+	Typed   ElementColor `toml:"typed"`   // Characters that were typed correctly
+	Mistake ElementColor `toml:"mistake"` // Characters that were typed incorrectly
+	Cursor  ElementColor `toml:"cursor"`  // Character the user is on
+	Accent  ElementColor `toml:"accent"`  // Numbers on the stats screen
+}
+
+// Represents the colors of a single element. Empty values keep the theme's
+// color.
+type ElementColor struct {
+	Foreground string `toml:"foreground"`
+	Background string `toml:"background"`
+}
+
+// Read the config file in dir. Returns an empty config if the file doesn't
+// exist.
+func loadConfig(dir string) (Config, error) {
+	var cfg Config
+
+	_, err := toml.DecodeFile(filepath.Join(dir, configFile), &cfg)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	} else if err != nil {
+		return Config{}, fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	if cfg.Duration < 0 || cfg.Duration > maxDuration {
+		return Config{}, fmt.Errorf("invalid duration: %v (expected 0 to %v seconds)", cfg.Duration, maxDuration)
+	}
+
+	if cfg.Words < 0 || cfg.Words > maxWords {
+		return Config{}, fmt.Errorf("invalid word count: %v (expected 0 to %v words)", cfg.Words, maxWords)
+	}
+
+	if cfg.Snippets != "" {
+		if err := checkSnippets(cfg.Snippets); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if cfg.MinWPM < 0 {
+		return Config{}, fmt.Errorf("invalid minimum speed: %v (expected 0 or more words per minute)", cfg.MinWPM)
+	}
+
+	if cfg.Tier < 0 {
+		return Config{}, fmt.Errorf("invalid tier: %v (expected 0 or more words)", cfg.Tier)
+	}
+
+	if cfg.Theme != "" {
+		if _, err := lookupTheme(cfg.Theme); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.Colors.validate(); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.History.validate(); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Budget.validate(); err != nil {
+		return Config{}, err
+	}
+
+	for name, p := range cfg.Presets {
+		if err := p.validate(name); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// Make sure every color is one lipgloss can draw.
+func (c ColorConfig) validate() error {
+	elements := map[string]ElementColor{
+		"prompt":  c.Prompt,
+		"typed":   c.Typed,
+		"mistake": c.Mistake,
+		"cursor":  c.Cursor,
+		"accent":  c.Accent,
+	}
+
+	for name, e := range elements {
+		for _, color := range []string{e.Foreground, e.Background} {
+			if color != "" && !colorPattern.MatchString(color) {
+				return fmt.Errorf("invalid color for %v: %q (expected a hex code like \"#ff0000\" or an ANSI color number)", name, color)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Get a copy of the theme with the overridden colors applied.
+func (c ColorConfig) apply(theme Theme) Theme {
+	theme.prompt = c.Prompt.apply(theme.prompt)
+	theme.typed = c.Typed.apply(theme.typed)
+	theme.mistake = c.Mistake.apply(theme.mistake)
+	theme.cursor = c.Cursor.apply(theme.cursor)
+	theme.accent = c.Accent.apply(theme.accent)
+	return theme
+}
+
+func (e ElementColor) apply(style lipgloss.Style) lipgloss.Style {
+	if e.Foreground != "" {
+		style = style.Foreground(lipgloss.Color(e.Foreground))
+	}
+
+	if e.Background != "" {
+		style = style.Background(lipgloss.Color(e.Background))
+	}
+
+	return style
+}
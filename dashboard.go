@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Number of recent results the rolling average and best are taken over.
+const rollingWindow = 10
+
+// Represents the summary of recent practice shown above the menu.
+type dashboard struct {
+	today   int     // Tests finished today
+	average float64 // Average WPM of the latest tests
+	best    float64 // Best WPM of the latest tests
+	recent  int     // Number of tests the average and best are taken over
+	streak  int     // Days in a row with at least one test, up to today
+}
+
+// Summarize results as of now. Incomplete tests don't count.
+func newDashboard(results []Result, now time.Time) dashboard {
+	var d dashboard
+	days := make(map[string]bool)
+
+	for i := len(results) - 1; i >= 0; i-- {
+		r := results[i]
+		if r.Incomplete {
+			continue
+		}
+
+		if sameDay(r.Time, now) {
+			d.today++
+		}
+
+		if d.recent < rollingWindow {
+			d.average += r.WPM
+			d.best = max(d.best, r.WPM)
+			d.recent++
+		}
+
+		days[r.Time.Local().Format(time.DateOnly)] = true
+	}
+
+	if d.recent > 0 {
+		d.average /= float64(d.recent)
+	}
+
+	// A streak isn't broken until a whole day goes by without practice, so it
+	// counts from yesterday if there's been no test yet today.
+	day := now
+	if d.today == 0 {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	for days[day.Local().Format(time.DateOnly)] {
+		d.streak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	return d
+}
+
+// Report whether two times fall on the same local day.
+func sameDay(a time.Time, b time.Time) bool {
+	return a.Local().Format(time.DateOnly) == b.Local().Format(time.DateOnly)
+}
+
+// Render the dashboard as a single line, or nothing before the first test.
+func (m Model) dashboardView() string {
+	d := m.dashboard
+	if d.recent == 0 {
+		return ""
+	}
+
+	accent := m.settings.theme.accent
+
+	s := fmt.Sprintf("Today: %v tests | ", accent.Render(fmt.Sprint(d.today)))
+	s += fmt.Sprintf("Last %v: %v wpm avg, %v best | ", d.recent, accent.Render(fmt.Sprintf("%.0f", d.average)), accent.Render(fmt.Sprintf("%.0f", d.best)))
+	s += fmt.Sprintf("Streak: %v days", accent.Render(fmt.Sprint(d.streak)))
+
+	if budget := m.budgetView(); budget != "" {
+		s += "\n" + budget
+	}
+
+	return s + "\n\n"
+}
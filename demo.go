@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math/rand/v2"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Speed the demo types at, in words per minute, unless another is given.
+const demoWPMDefault = 60
+
+// How long the demo waits before typing a new prompt, so it can be read.
+const demoStart = time.Second
+
+// How long the stats of a demo test are shown before the next one starts.
+const demoPause = 5 * time.Second
+
+// Sent when the demo typist is due to press the next key.
+type demoKeyMsg time.Time
+
+// Sent when the demo is due to start the next test.
+type demoNextMsg time.Time
+
+// Get a typist that types the demo at the given speed, making and fixing
+// mistakes now and then like a careful typist would.
+func newDemoTypist(wpm int) *engine.Typist {
+	p := engine.Steady
+	p.WPM = float64(wpm)
+	return engine.NewTypist(p, rand.Uint64())
+}
+
+// Get the command that has the demo typist press the next key after d.
+func demoKey(d time.Duration) tea.Cmd {
+	return tea.Tick(d, func(t time.Time) tea.Msg {
+		return demoKeyMsg(t)
+	})
+}
+
+// Get the command that starts the next demo test once its stats were shown.
+func demoNext() tea.Cmd {
+	return tea.Tick(demoPause, func(t time.Time) tea.Msg {
+		return demoNextMsg(t)
+	})
+}
+
+// Manages the prompt and stats screens while the demo types on its own. Tests
+// follow one another until any key is pressed, which quits.
+func (m Model) updateDemo(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case tea.KeyMsg:
+		return m, tea.Quit
+
+	case tickMsg:
+		if m.view == PROMPT && m.test.Expired() {
+			return m, tea.Batch(tick(), m.finish())
+		}
+
+		if m.view == PROMPT {
+			m.test.Tick()
+			if m.failed() {
+				return m, tea.Batch(tick(), m.finish())
+			}
+		}
+
+		return m, tick()
+
+	case demoKeyMsg:
+		if m.view != PROMPT {
+			return m, nil
+		}
+
+		wait := m.demo.Step(m.test)
+		if m.test.State() == engine.DONE {
+			return m, m.finish()
+		}
+
+		return m, demoKey(wait)
+
+	case demoNextMsg:
+		return m.reroll(), demoKey(demoStart)
+	}
+
+	return m, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Represents how mistakes are dealt with during a test.
+type Difficulty int16
+
+const (
+	NORMAL    Difficulty = iota // Mistakes only cost accuracy
+	EXPERT                      // Finishing a word with a mistake in it fails the test
+	MASTER                      // Any wrong key fails the test
+	FORGIVING                   // A word can't be finished until its mistakes are fixed
+)
+
+// Every difficulty, in the order they are listed to the user.
+var difficulties = []Difficulty{NORMAL, EXPERT, MASTER, FORGIVING}
+
+// Get the name of a difficulty, as used on the command line, in the config
+// file, and in the history.
+func (d Difficulty) String() string {
+	switch d {
+	case EXPERT:
+		return "expert"
+	case MASTER:
+		return "master"
+	case FORGIVING:
+		return "forgiving"
+	default:
+		return "normal"
+	}
+}
+
+// Get a difficulty from its name.
+func parseDifficulty(name string) (Difficulty, error) {
+	for _, d := range difficulties {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+
+	return NORMAL, fmt.Errorf("unknown difficulty: %v", name)
+}
+
+// Allows the difficulty to be read from the config file by name.
+func (d *Difficulty) UnmarshalText(text []byte) error {
+	difficulty, err := parseDifficulty(string(text))
+	*d = difficulty
+	return err
+}
+
+// Allows the difficulty to be written to the config file by name.
+func (d Difficulty) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// Time at the start of a test before the minimum speed is enforced, since
+// the speed swings wildly over the first few words.
+const minWPMGrace = 5 * time.Second
+
+// Get the rules a test is played by: the ones the difficulty adds, and the
+// minimum speed, if there is one. Forgiving mode isn't a rule, since it stops
+// words from being finished rather than failing the test. Kids mode has no
+// way to fail, so it has no rules.
+func (s Settings) rules() []engine.Rule {
+	if s.mode == KIDS {
+		return nil
+	}
+
+	var rules []engine.Rule
+	switch s.difficulty {
+	case EXPERT:
+		rules = append(rules, engine.StrictWords{})
+	case MASTER:
+		rules = append(rules, engine.SuddenDeath{})
+	}
+
+	if s.minWPM > 0 {
+		rules = append(rules, engine.MinWPM{WPM: float64(s.minWPM), Grace: minWPMGrace})
+	}
+
+	return rules
+}
+
+// Report whether typing g would finish the word the user is on while it still
+// has a mistake in it, or with one, which forgiving mode doesn't allow.
+func (m Model) unforgiven(g string) bool {
+	if m.settings.difficulty != FORGIVING || m.mode == KIDS || m.test.Free() {
+		return false
+	}
+
+	cells := m.test.Cells()
+	cursor := m.test.Cursor()
+	if cursor >= len(cells) {
+		return false
+	}
+
+	// Only the space after a word, or the last character of the prompt,
+	// finishes it.
+	if !cells[cursor].Space() && cursor != len(cells)-1 {
+		return false
+	}
+
+	if !cells[cursor].Matches(g) {
+		return true
+	}
+
+	for i := cursor - 1; i >= 0 && !cells[i].Space(); i-- {
+		if cells[i].State == engine.WRONG {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Report whether one of the test's rules failed it.
+func (m Model) failed() bool {
+	return m.test.Failure() != nil
+}
+
+// Get why the test was failed, for the stats screen.
+func (m Model) failureView() string {
+	return fmt.Sprintf("Test failed: %v.\n\n", m.test.Failure())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+func TestKidsModeCantFail(t *testing.T) {
+	for _, difficulty := range difficulties {
+		settings := Settings{mode: KIDS, difficulty: difficulty, minWPM: 40}
+		if rules := settings.rules(); len(rules) > 0 {
+			t.Errorf("%v: kids mode has %v rules, want none", difficulty, len(rules))
+		}
+
+		clock := engine.NewManualClock(time.Now())
+		test := engine.New("cat dog", 0, clock)
+		for _, rule := range settings.rules() {
+			test.AddRule(rule)
+		}
+
+		m := Model{test: test, mode: KIDS, settings: settings}
+		for _, c := range "cxt" {
+			clock.Advance(10 * time.Second)
+			test.Type(c)
+			test.Tick()
+		}
+
+		if m.failed() {
+			t.Errorf("%v: kids test failed: %v", difficulty, test.Failure())
+		}
+
+		if m.unforgiven(" ") {
+			t.Errorf("%v: kids test won't let a word with a mistake be finished", difficulty)
+		}
+	}
+}
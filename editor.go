@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Represents a part of a file to type in code mode, chosen from an editor,
+// e.g. in Vim:
+//
+//	:!typing-tui drill --file % --lines 40:60
+type editorDrill struct {
+	file   string // File the code is read from
+	first  int    // First line of the code, counting from 1
+	last   int    // Last line of the code
+	asJSON bool   // Print the result as a report instead of a line of text
+	text   string // Code to type
+}
+
+// Get the drill chosen by the arguments of the drill command. Reports false
+// if they're invalid, once the problem is printed.
+func parseEditorDrill(args []string) (editorDrill, bool) {
+	d, err := readEditorDrill(args)
+	if errors.Is(err, errFlags) {
+		return editorDrill{}, false
+	} else if err != nil {
+		fmt.Println(err)
+		return editorDrill{}, false
+	}
+
+	return d, true
+}
+
+// Returned when the flags of the drill command are invalid, which the flag
+// package has already printed.
+var errFlags = errors.New("invalid flags")
+
+// Read the arguments of the drill command, and the code they choose.
+func readEditorDrill(args []string) (editorDrill, error) {
+	var d editorDrill
+
+	flags := flag.NewFlagSet("drill", flag.ContinueOnError)
+	flags.StringVar(&d.file, "file", "", "file to read the code from")
+	lines := flags.String("lines", "", "lines to type, e.g. 40:60, 40, or 40: for the rest of the file (default all)")
+	flags.BoolVar(&d.asJSON, "json", false, "print the result as a JSON report")
+	if err := flags.Parse(args); err != nil {
+		return editorDrill{}, errFlags
+	}
+
+	if d.file == "" {
+		return editorDrill{}, errors.New("drill needs a file: pass --file")
+	}
+
+	data, err := os.ReadFile(d.file)
+	if err != nil {
+		return editorDrill{}, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	all := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	d.first, d.last, err = parseLines(*lines, len(all))
+	if err != nil {
+		return editorDrill{}, err
+	}
+
+	d.text = sanitizeCode(strings.Join(all[d.first-1:d.last], "\n"))
+	if d.text == "" {
+		return editorDrill{}, fmt.Errorf("lines %v to %v of %v are empty", d.first, d.last, d.file)
+	}
+
+	return d, nil
+}
+
+// Get the first and last line of a range like "40:60", "40", or "40:", in a
+// file with the given number of lines. An empty range is the whole file.
+func parseLines(s string, total int) (int, int, error) {
+	if s == "" {
+		return 1, total, nil
+	}
+
+	from, to, ranged := strings.Cut(s, ":")
+	first, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid line range: %v", s)
+	}
+
+	last := first
+	if ranged {
+		last = total
+		if to != "" {
+			last, err = strconv.Atoi(to)
+			if err != nil {
+				return 0, 0, fmt.Errorf("invalid line range: %v", s)
+			}
+		}
+	}
+
+	if first < 1 || last < first {
+		return 0, 0, fmt.Errorf("invalid line range: %v", s)
+	}
+
+	if first > total {
+		return 0, 0, fmt.Errorf("line %v is past the end of the file (%v lines)", first, total)
+	}
+
+	return first, min(last, total), nil
+}
+
+// Apply the drill to the settings: the code is typed in code mode.
+func (d editorDrill) apply(settings Settings) Settings {
+	settings.mode = CODE
+	settings.text = d.text
+	settings.chunks = nil
+	settings.playlist = nil
+	return settings
+}
+
+// Print the result of the drill once the program has quit, for the editor to
+// show. Returns the exit code: 1 if the code was never typed.
+func (d editorDrill) print(final tea.Model) int {
+	m := unwrap(final)
+	if m.test == nil || m.test.State() != engine.DONE || m.discarded {
+		fmt.Println("drill cancelled")
+		return 1
+	}
+
+	if d.asJSON {
+		data, err := json.Marshal(m.report())
+		if err != nil {
+			fmt.Printf("failed to encode json: %v\n", err)
+			return 1
+		}
+
+		fmt.Println(string(data))
+		return 0
+	}
+
+	r := m.result()
+	incomplete := ""
+	if r.Incomplete {
+		incomplete = " (incomplete)"
+	}
+
+	fmt.Printf(
+		"%v:%v-%v  %.2f WPM (raw %.2f, %.2f%% accuracy, %v mistakes) in %.1fs%v\n",
+		d.file,
+		d.first,
+		d.last,
+		r.WPM,
+		r.Raw,
+		r.Accuracy,
+		r.Mistakes,
+		r.Elapsed,
+		incomplete,
+	)
+
+	return 0
+}
+
+// Get somewhere to draw the program when stdout is being read by an editor
+// rather than shown in a terminal, or nil to draw on stdout.
+func editorOutput() *os.File {
+	info, err := os.Stdout.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return nil
+	}
+
+	tty, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0)
+	if err != nil {
+		return nil
+	}
+
+	return tty
+}
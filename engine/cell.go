@@ -0,0 +1,64 @@
+package engine
+
+import (
+	"time"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Represents how a single character of the prompt has been typed.
+type CellState int16
+
+const (
+	PENDING   CellState = iota // Not typed yet
+	CORRECT                    // Typed correctly the first time
+	WRONG                      // Typed incorrectly
+	CORRECTED                  // Typed correctly after being typed incorrectly and erased
+	SKIPPED                    // Passed over without being typed
+)
+
+// Represents a single character of the prompt: what was asked for, what was
+// typed in its place, and when.
+type Cell struct {
+	Expected string    // Grapheme the prompt asks for
+	Typed    string    // Grapheme the user typed, or empty if none
+	State    CellState // How the cell has been typed
+	Errors   int       // Number of times the cell was typed incorrectly
+	TypedAt  time.Time // When the cell was last typed
+}
+
+// Split a prompt into cells, one per grapheme, so characters made of several
+// code points (e.g. accented letters) are treated as one.
+func newCells(prompt string) []Cell {
+	var cells []Cell
+
+	g := uniseg.NewGraphemes(prompt)
+	for g.Next() {
+		cells = append(cells, Cell{Expected: g.Str()})
+	}
+
+	return cells
+}
+
+// Report whether the cell counts as typed correctly.
+func (c Cell) Correct() bool {
+	return c.State == CORRECT || c.State == CORRECTED
+}
+
+// Report whether typing g in the cell would be correct.
+func (c Cell) Matches(g string) bool {
+	return sameGrapheme(g, c.Expected)
+}
+
+// Report whether the cell is the space between two words, or the end of a
+// line.
+func (c Cell) Space() bool {
+	return c.Expected == " " || c.Expected == "\n"
+}
+
+// Report whether two graphemes are the same once normalized (NFC), e.g. "é"
+// as one code point and as "e" followed by a combining accent.
+func sameGrapheme(a string, b string) bool {
+	return a == b || norm.NFC.String(a) == norm.NFC.String(b)
+}
@@ -0,0 +1,43 @@
+package engine
+
+import "time"
+
+// Represents a source of the current time. The engine never reads the
+// computer's clock directly, so tests, replays, and simulations can decide how
+// fast time passes.
+type Clock interface {
+	Now() time.Time
+}
+
+// Reads the computer's clock.
+type SystemClock struct{}
+
+// Get the current time.
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Represents a clock that only moves when told to.
+type ManualClock struct {
+	now time.Time
+}
+
+// Create a clock that starts at the given time.
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Get the clock's current time.
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+// Move the clock forward.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// Move the clock to the given time.
+func (c *ManualClock) Set(t time.Time) {
+	c.now = t
+}
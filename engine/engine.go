@@ -0,0 +1,362 @@
+// Package engine runs a typing test: it scores keystrokes against a prompt and
+// keeps track of time, without knowing anything about how the test is drawn.
+package engine
+
+import (
+	"time"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Represents the user's current action.
+type State int16
+
+const (
+	READY  State = iota // User can start typing
+	TYPING              // User is typing
+	DONE                // Test completed
+)
+
+// Represents a single typing test.
+type Engine struct {
+	clock    Clock         // Source of the current time
+	cells    []Cell        // Characters of the prompt, and how each was typed
+	cursor   int           // Index of the cell the user is on
+	limit    time.Duration // Time limit, or 0 for none
+	typed    int           // Counter for characters typed
+	mistakes int           // Counter for typos
+	state    State         // Current action
+	start    time.Time     // When the user started typing
+	elapsed  time.Duration // Time spent typing, set once the test is done
+	paused   time.Time     // When the test was paused, or zero if it's running
+	pauses   time.Duration // Time spent paused before the current pause
+	free     bool          // Whether there is no prompt, and the user types anything
+	forgive  bool          // Whether erasing a mistake takes it back
+	rules    []Rule        // Rules the test is played by
+	failure  error         // Why a rule failed the test, or nil
+
+	listeners []func(Event) // Called with every event the test sends
+}
+
+// Represents the statistics of a test.
+type Score struct {
+	Correct  int           // Counter for correct keystrokes
+	Mistakes int           // Counter for typos
+	Elapsed  time.Duration // Time spent typing
+	WPM      float64       // Words per minute, excluding mistakes
+	Raw      float64       // Words per minute, including mistakes
+	Accuracy float64       // Percentage of correct keystrokes
+}
+
+// Create a test for the given prompt. A limit of 0 means the test only ends
+// once the whole prompt is typed.
+func New(prompt string, limit time.Duration, clock Clock) *Engine {
+	return &Engine{
+		clock: clock,
+		cells: newCells(prompt),
+		limit: limit,
+		state: READY,
+	}
+}
+
+// Create a test without a prompt: every character typed is correct, and the
+// test only ends once Finish is called or the time runs out.
+func NewFree(limit time.Duration, clock Clock) *Engine {
+	e := New("", limit, clock)
+	e.free = true
+	return e
+}
+
+// Change the time limit. Has no effect once the test has started.
+func (e *Engine) SetLimit(limit time.Duration) {
+	if e.state == READY {
+		e.limit = limit
+	}
+}
+
+// Score a single keystroke. The first keystroke starts the test, and typing
+// the last character of the prompt ends it. Returns the grapheme the prompt
+// asked for, or false if the test is already over. Without a prompt, the
+// character typed is added to it.
+func (e *Engine) Type(c rune) (string, bool) {
+	return e.TypeGrapheme(string(c))
+}
+
+// Score a keystroke that typed a whole grapheme, e.g. an emoji made of several
+// code points, as with Type. Graphemes are compared once normalized, so an
+// accented letter matches whether it's one code point or a letter followed by
+// an accent.
+func (e *Engine) TypeGrapheme(g string) (string, bool) {
+	if g == "" {
+		return "", false
+	}
+
+	if e.state == DONE || (e.cursor >= len(e.cells) && !e.free) {
+		return "", false
+	}
+
+	e.Resume()
+
+	now := e.clock.Now()
+	if e.state == READY {
+		e.state = TYPING
+		e.start = now
+		e.emit(TestStarted{Time: now})
+	}
+
+	if e.free && e.cursor == len(e.cells) {
+		e.cells = append(e.cells, Cell{Expected: g})
+	}
+
+	position := e.cursor
+	cell := &e.cells[position]
+	cell.Typed = g
+	cell.TypedAt = now
+	e.typed++
+
+	switch {
+	case !cell.Matches(cell.Typed):
+		cell.State = WRONG
+		cell.Errors++
+		e.mistakes++
+	case cell.Errors > 0:
+		cell.State = CORRECTED
+	default:
+		cell.State = CORRECT
+	}
+
+	e.cursor++
+
+	k := KeystrokeScored{
+		Time:     now,
+		Position: position,
+		Expected: cell.Expected,
+		Typed:    norm.NFC.String(g),
+		Correct:  cell.Correct(),
+	}
+
+	e.emit(k)
+	if e.check(func(r Rule) error { return r.OnKeystroke(k) }) {
+		return cell.Expected, true
+	}
+
+	end := !e.free && e.cursor == len(e.cells)
+	if cell.Space() || end {
+		e.completeWord(position, now)
+	}
+
+	if end {
+		e.Finish()
+	}
+
+	return cell.Expected, true
+}
+
+// Remove the last character typed. Mistakes are still counted, and a cell
+// that was typed incorrectly becomes CORRECTED once it is typed correctly,
+// unless erased mistakes are forgiven. Without a prompt, the character is
+// removed from it.
+func (e *Engine) Backspace() {
+	if e.state != TYPING || e.cursor == 0 {
+		return
+	}
+
+	e.cursor--
+	e.emit(KeystrokeErased{Time: e.clock.Now(), Position: e.cursor})
+	if e.free {
+		e.cells = e.cells[:e.cursor]
+		return
+	}
+
+	cell := &e.cells[e.cursor]
+	if e.forgive && cell.State == WRONG {
+		cell.Errors--
+		e.mistakes--
+		e.typed--
+	}
+
+	cell.Typed = ""
+	cell.State = PENDING
+}
+
+// Remove the word before the cursor, and the spaces typed after it, as if
+// Backspace was pressed for each character.
+func (e *Engine) BackspaceWord() {
+	for e.state == TYPING && e.cursor > 0 && e.cells[e.cursor-1].Space() {
+		e.Backspace()
+	}
+
+	for e.state == TYPING && e.cursor > 0 && !e.cells[e.cursor-1].Space() {
+		e.Backspace()
+	}
+}
+
+// Choose whether erasing a mistake takes it back, so mistakes that are fixed
+// don't count against the score.
+func (e *Engine) SetForgive(forgive bool) {
+	e.forgive = forgive
+}
+
+// Jump to the start of the next word, marking the rest of the current word and
+// the space after it as SKIPPED. Skipped cells count as neither typed nor
+// mistaken, but the word is not correct.
+func (e *Engine) Skip() {
+	if e.state != TYPING || e.free {
+		return
+	}
+
+	for e.cursor < len(e.cells) {
+		e.cells[e.cursor].State = SKIPPED
+		e.cursor++
+
+		if e.cells[e.cursor-1].Space() {
+			break
+		}
+	}
+
+	e.completeWord(e.cursor-1, e.clock.Now())
+
+	if e.cursor >= len(e.cells) {
+		e.Finish()
+	}
+}
+
+// Stop the clock, e.g. while the user is away. Has no effect unless the user
+// is typing.
+func (e *Engine) Pause() {
+	if e.state != TYPING || e.Paused() {
+		return
+	}
+
+	e.paused = e.clock.Now()
+	e.emit(TestPaused{Time: e.paused})
+}
+
+// Start the clock again after a pause. Typing resumes the test on its own.
+func (e *Engine) Resume() {
+	if !e.Paused() {
+		return
+	}
+
+	now := e.clock.Now()
+	e.pauses += now.Sub(e.paused)
+	e.paused = time.Time{}
+	e.emit(TestResumed{Time: now, Paused: e.pauses})
+}
+
+// Report whether the clock is stopped.
+func (e *Engine) Paused() bool {
+	return !e.paused.IsZero()
+}
+
+// Report whether the time limit has been reached.
+func (e *Engine) Expired() bool {
+	return e.state == TYPING && e.limit > 0 && e.Elapsed() >= e.limit
+}
+
+// End the test. A timed test never counts more time than its limit.
+func (e *Engine) Finish() {
+	if e.state == DONE {
+		return
+	}
+
+	e.elapsed = e.Elapsed()
+	if e.limit > 0 {
+		e.elapsed = min(e.elapsed, e.limit)
+	}
+
+	e.state = DONE
+	e.paused = time.Time{}
+	e.emit(TestFinished{Time: e.start.Add(e.pauses + e.elapsed), Score: e.Score()})
+}
+
+// Get the time spent typing so far.
+func (e *Engine) Elapsed() time.Duration {
+	switch e.state {
+	case TYPING:
+		now := e.clock.Now()
+		if e.Paused() {
+			now = e.paused
+		}
+
+		return now.Sub(e.start) - e.pauses
+	case DONE:
+		return e.elapsed
+	default:
+		return 0
+	}
+}
+
+// Calculate the statistics for the test.
+func (e *Engine) Score() Score {
+	return NewScore(e.typed-e.mistakes, e.mistakes, e.Elapsed())
+}
+
+// Calculate the statistics for a number of correct keystrokes and typos over
+// the time spent typing. Every score is worked out here, so results saved
+// before a change to the formulas can be scored again the same way.
+func NewScore(correct int, mistakes int, elapsed time.Duration) Score {
+	typed := correct + mistakes
+	seconds := elapsed.Seconds()
+
+	var wpm, raw float64
+	if seconds > 0 {
+		wpm = (float64(correct) / 5.0) * (60.0 / seconds)
+		raw = (float64(typed) / 5.0) * (60.0 / seconds)
+	}
+
+	var accuracy float64
+	if typed > 0 {
+		accuracy = (1.0 - (float64(mistakes) / float64(typed))) * 100.0
+	}
+
+	return Score{
+		Correct:  correct,
+		Mistakes: mistakes,
+		Elapsed:  elapsed,
+		WPM:      wpm,
+		Raw:      raw,
+		Accuracy: accuracy,
+	}
+}
+
+// Get the text the user is asked to type.
+func (e *Engine) Prompt() string {
+	s := ""
+	for _, cell := range e.cells {
+		s += cell.Expected
+	}
+
+	return s
+}
+
+// Get every character of the prompt, and how each was typed. The cells belong
+// to the engine and must not be changed.
+func (e *Engine) Cells() []Cell {
+	return e.cells
+}
+
+// Get the index of the cell the user is on.
+func (e *Engine) Cursor() int {
+	return e.cursor
+}
+
+// Report whether the test has no prompt.
+func (e *Engine) Free() bool {
+	return e.free
+}
+
+// Get the time limit, or 0 if there is none.
+func (e *Engine) Limit() time.Duration {
+	return e.limit
+}
+
+// Get the user's current action.
+func (e *Engine) State() State {
+	return e.state
+}
+
+// Get when the user started typing.
+func (e *Engine) Started() time.Time {
+	return e.start
+}
@@ -0,0 +1,132 @@
+package engine
+
+import "time"
+
+// Represents something that happened during a test. Front ends, sound
+// effects, and anything else that reacts to the test should listen for events
+// instead of inspecting the engine after every keystroke.
+type Event interface {
+	event()
+}
+
+// Sent when the first key is pressed.
+type TestStarted struct {
+	Time time.Time // When the test started
+}
+
+// Sent for every character typed.
+type KeystrokeScored struct {
+	Time     time.Time // When the key was pressed
+	Position int       // Index of the character in the prompt
+	Expected string    // Grapheme the prompt asked for
+	Typed    string    // Grapheme the user typed, normalized (NFC)
+	Correct  bool      // Whether the two match
+}
+
+// Sent for every character erased with backspace.
+type KeystrokeErased struct {
+	Time     time.Time // When the key was pressed
+	Position int       // Index of the character erased
+}
+
+// Sent when the space after a word, or the last character of the prompt, is
+// typed.
+type WordCompleted struct {
+	Time    time.Time // When the word was completed
+	Index   int       // Number of words before this one in the prompt
+	Word    string    // Word the prompt asked for
+	Typed   string    // What the user typed in its place
+	Correct bool      // Whether the two match
+}
+
+// Sent once the test is over, either because the prompt was typed or the time
+// ran out.
+type TestFinished struct {
+	Time  time.Time // When the test ended
+	Score Score     // Final statistics
+}
+
+// Sent when a rule fails the test, just before it ends.
+type TestFailed struct {
+	Time   time.Time // When the test was failed
+	Reason error     // Why the rule failed it
+}
+
+// Sent when the clock is stopped mid-test.
+type TestPaused struct {
+	Time time.Time // When the test was paused
+}
+
+// Sent when the clock starts again after a pause.
+type TestResumed struct {
+	Time   time.Time     // When the test was resumed
+	Paused time.Duration // Total time the test has spent paused
+}
+
+func (TestStarted) event()     {}
+func (KeystrokeScored) event() {}
+func (KeystrokeErased) event() {}
+func (WordCompleted) event()   {}
+func (TestFinished) event()    {}
+func (TestFailed) event()      {}
+func (TestPaused) event()      {}
+func (TestResumed) event()     {}
+
+// Call fn with every event the test sends from now on, in the order they
+// happen. Listeners are called synchronously, so they should return quickly.
+func (e *Engine) Subscribe(fn func(Event)) {
+	e.listeners = append(e.listeners, fn)
+}
+
+// Send an event to every listener.
+func (e *Engine) emit(ev Event) {
+	for _, fn := range e.listeners {
+		fn(ev)
+	}
+}
+
+// Send a WordCompleted event for the word ending at position, which is either
+// the space after the word or its last character.
+func (e *Engine) completeWord(position int, now time.Time) {
+	end := position
+	if !e.cells[end].Space() {
+		end++
+	}
+
+	start := end
+	for start > 0 && !e.cells[start-1].Space() {
+		start--
+	}
+
+	// Spaces in a row, like the indentation of code, have no word between
+	// them.
+	if start == end {
+		return
+	}
+
+	index := 0
+	for i, cell := range e.cells[:start] {
+		if cell.Space() && i > 0 && !e.cells[i-1].Space() {
+			index++
+		}
+	}
+
+	var word, typed string
+	correct := true
+	for _, cell := range e.cells[start:end] {
+		word += cell.Expected
+		typed += cell.Typed
+		correct = correct && cell.Correct()
+	}
+
+	w := WordCompleted{
+		Time:    now,
+		Index:   index,
+		Word:    word,
+		Typed:   typed,
+		Correct: correct,
+	}
+
+	e.emit(w)
+	e.check(func(r Rule) error { return r.OnWordCommit(w) })
+}
@@ -0,0 +1,113 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Represents a rule a test is played by on top of the usual scoring, e.g. to
+// end it at the first mistake. Rules are checked as the test goes, and the
+// first one to return an error fails the test, which ends it. Custom game
+// modes can be made by adding rules of their own to a test.
+type Rule interface {
+	// Called for every character typed.
+	OnKeystroke(k KeystrokeScored) error
+
+	// Called for every word completed, including words that are skipped.
+	OnWordCommit(w WordCompleted) error
+
+	// Called with the score so far every time Tick is, while the user is
+	// typing.
+	OnTick(s Score) error
+}
+
+// Does nothing. Embed it in a rule to only implement the methods it needs.
+type BaseRule struct{}
+
+func (BaseRule) OnKeystroke(KeystrokeScored) error { return nil }
+func (BaseRule) OnWordCommit(WordCompleted) error  { return nil }
+func (BaseRule) OnTick(Score) error                { return nil }
+
+// Fails the test at the first wrong key.
+type SuddenDeath struct {
+	BaseRule
+}
+
+func (SuddenDeath) OnKeystroke(k KeystrokeScored) error {
+	if !k.Correct {
+		return errors.New("a wrong key was pressed")
+	}
+
+	return nil
+}
+
+// Fails the test when a word is finished with a mistake still in it. Mistakes
+// fixed before moving on are allowed.
+type StrictWords struct {
+	BaseRule
+}
+
+func (StrictWords) OnWordCommit(w WordCompleted) error {
+	if !w.Correct {
+		return errors.New("a word was finished with a mistake in it")
+	}
+
+	return nil
+}
+
+// Fails the test when the speed drops below a minimum, once the user has had
+// some time to get going.
+type MinWPM struct {
+	BaseRule
+	WPM   float64       // Slowest speed allowed, in words per minute
+	Grace time.Duration // Time at the start of the test the speed isn't checked
+}
+
+func (r MinWPM) OnTick(s Score) error {
+	if s.Elapsed >= r.Grace && s.WPM < r.WPM {
+		return fmt.Errorf("the speed dropped below %v WPM", r.WPM)
+	}
+
+	return nil
+}
+
+// Play the test by a rule from now on, along with any added before it.
+func (e *Engine) AddRule(r Rule) {
+	e.rules = append(e.rules, r)
+}
+
+// Get why a rule failed the test, or nil if none did.
+func (e *Engine) Failure() error {
+	return e.failure
+}
+
+// Check the rules that depend on time, e.g. a minimum speed. Meant to be
+// called regularly while the test is shown, like the clock is checked.
+func (e *Engine) Tick() {
+	if e.state != TYPING || e.Paused() {
+		return
+	}
+
+	score := e.Score()
+	e.check(func(r Rule) error { return r.OnTick(score) })
+}
+
+// Check every rule with fn, failing and ending the test with the first error.
+// Returns whether the test was failed.
+func (e *Engine) check(fn func(r Rule) error) bool {
+	if e.failure != nil {
+		return true
+	}
+
+	for _, r := range e.rules {
+		if err := fn(r); err != nil {
+			e.failure = err
+			e.emit(TestFailed{Time: e.clock.Now(), Reason: err})
+			e.Finish()
+			return true
+		}
+	}
+
+	return false
+}
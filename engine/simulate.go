@@ -0,0 +1,141 @@
+package engine
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Represents how a simulated typist types: how fast, how evenly, and how
+// often they make and fix mistakes.
+type Profile struct {
+	WPM       float64 // Average speed in words per minute, counting 5 characters as a word
+	Jitter    float64 // How much the time between keystrokes varies, as a fraction of the average (0 to 1)
+	ErrorRate float64 // Chance of typing any character incorrectly (0 to 1)
+	FixRate   float64 // Chance of erasing a mistake and typing it again right away (0 to 1)
+}
+
+// Profiles to start from when simulating tests.
+var (
+	// A careful typist who rarely makes mistakes and fixes almost all of them.
+	Steady = Profile{WPM: 60, Jitter: 0.1, ErrorRate: 0.02, FixRate: 0.9}
+
+	// A fast, erratic typist who makes mistakes all the time and leaves most
+	// of them, for stress testing scoring and custom rules.
+	TypoStorm = Profile{WPM: 120, Jitter: 0.6, ErrorRate: 0.3, FixRate: 0.25}
+)
+
+// Characters typed in place of the right one when a simulated typist makes a
+// mistake, and typed at random in free tests.
+const simulatedKeys = "abcdefghijklmnopqrstuvwxyz"
+
+// Number of characters typed in a free test without a time limit, which
+// otherwise never ends.
+const simulatedFreeLength = 250
+
+// Types a test one keystroke at a time as a simulated typist would, so the
+// test can be watched as it's typed.
+type Typist struct {
+	profile Profile
+	rng     *rand.Rand
+	gap     time.Duration // Average time between keystrokes
+	typo    string        // Expected grapheme of the mistake just typed, or empty if none
+	fixing  bool          // Whether the mistake was erased and is to be typed again
+}
+
+// Create a simulated typist. The same seed always types a test the same way.
+func NewTypist(p Profile, seed uint64) *Typist {
+	gap := time.Minute
+	if p.WPM > 0 {
+		gap = time.Duration(float64(time.Minute) / (p.WPM * 5))
+	}
+
+	return &Typist{
+		profile: p,
+		rng:     rand.New(rand.NewPCG(seed, seed)),
+		gap:     gap,
+	}
+}
+
+// Press the next key of the test. Returns how long to wait before pressing
+// the one after it. Has no effect once the test is done.
+func (t *Typist) Step(e *Engine) time.Duration {
+	if e.State() == DONE {
+		return 0
+	}
+
+	if t.fixing {
+		e.TypeGrapheme(t.typo)
+		t.typo, t.fixing = "", false
+		return t.wait()
+	}
+
+	if t.typo != "" {
+		if t.rng.Float64() < t.profile.FixRate {
+			e.Backspace()
+			t.fixing = true
+			return t.wait()
+		}
+
+		t.typo = ""
+	}
+
+	if e.free {
+		if e.limit == 0 && e.cursor >= simulatedFreeLength {
+			e.Finish()
+			return 0
+		}
+
+		c := rune(simulatedKeys[t.rng.IntN(len(simulatedKeys))])
+		if t.rng.IntN(6) == 0 {
+			c = ' '
+		}
+
+		e.Type(c)
+		return t.wait()
+	}
+
+	// Characters made of several code points, like most emoji, are typed
+	// all at once, as they are on a real keyboard.
+	expected := e.cells[e.cursor].Expected
+	if t.rng.Float64() >= t.profile.ErrorRate {
+		e.TypeGrapheme(expected)
+		return t.wait()
+	}
+
+	e.TypeGrapheme(typo(t.rng, expected))
+	t.typo = expected
+	return t.wait()
+}
+
+// Get how long to wait before the next keystroke.
+func (t *Typist) wait() time.Duration {
+	d := time.Duration(float64(t.gap) * (1 + t.profile.Jitter*(2*t.rng.Float64()-1)))
+	return max(d, time.Millisecond)
+}
+
+// Type a whole test as a simulated typist would, moving the clock forward
+// between keystrokes, until the prompt is typed or the time runs out. The
+// clock must be the one the test was created with. The same seed always
+// types the test the same way, so results can be compared between runs.
+func Simulate(e *Engine, clock *ManualClock, p Profile, seed uint64) Score {
+	t := NewTypist(p, seed)
+	for e.State() != DONE {
+		clock.Advance(t.Step(e))
+
+		if e.Expired() {
+			e.Finish()
+		}
+	}
+
+	return e.Score()
+}
+
+// Pick a character to type by mistake in place of the expected one.
+func typo(rng *rand.Rand, expected string) string {
+	for {
+		c := string(simulatedKeys[rng.IntN(len(simulatedKeys))])
+		if !sameGrapheme(c, expected) {
+			return c
+		}
+	}
+}
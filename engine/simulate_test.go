@@ -0,0 +1,38 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulateTypesWholeGraphemes(t *testing.T) {
+	// "é" as "e" followed by a combining accent, and a thumbs up with a skin
+	// tone, are a single character made of two code points each.
+	prompt := "cafe\u0301 \U0001F44D\U0001F3FD"
+
+	clock := NewManualClock(time.Now())
+	test := New(prompt, 0, clock)
+
+	var keys []KeystrokeScored
+	test.Subscribe(func(ev Event) {
+		if k, ok := ev.(KeystrokeScored); ok {
+			keys = append(keys, k)
+		}
+	})
+
+	score := Simulate(test, clock, Profile{WPM: 60}, 1)
+	if score.Mistakes != 0 {
+		t.Errorf("got %v mistakes, want none", score.Mistakes)
+	}
+
+	want := []string{"c", "a", "f", "e\u0301", " ", "\U0001F44D\U0001F3FD"}
+	if len(keys) != len(want) {
+		t.Fatalf("got %v keystrokes, want %v", len(keys), len(want))
+	}
+
+	for i, k := range keys {
+		if k.Expected != want[i] || !k.Correct {
+			t.Errorf("keystroke %v: expected %q, typed %q, correct %v", i, k.Expected, k.Typed, k.Correct)
+		}
+	}
+}
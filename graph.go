@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of rows of text the speed graph is drawn with.
+const graphHeight = 6
+
+// Blocks used to draw the bars of the graph, from an eighth of a row to a full
+// row.
+var graphBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Group samples into at most width columns, averaging the speed in each and
+// adding up the mistakes, so long tests still fit on the screen.
+func bucketSamples(samples []Sample, width int) []Sample {
+	if len(samples) <= width {
+		return samples
+	}
+
+	buckets := make([]Sample, width)
+	for i := range buckets {
+		start := i * len(samples) / width
+		end := (i + 1) * len(samples) / width
+
+		for _, s := range samples[start:end] {
+			buckets[i].WPM += s.WPM
+			buckets[i].Raw += s.Raw
+			buckets[i].Mistakes += s.Mistakes
+		}
+
+		n := float64(end - start)
+		buckets[i].WPM /= n
+		buckets[i].Raw /= n
+		buckets[i].Second = samples[end-1].Second
+	}
+
+	return buckets
+}
+
+// Render a bar chart of the user's speed over the test, one column per second
+// (or group of seconds in long tests), with an x under every column that had
+// a mistake in it.
+func (m Model) graphView() string {
+	samples := bucketSamples(m.recorder.samples(m.test.Elapsed()), terminalWidthDefault)
+	if len(samples) < 2 {
+		return ""
+	}
+
+	top := 0.0
+	for _, s := range samples {
+		top = max(top, s.WPM)
+	}
+
+	if top == 0 {
+		return ""
+	}
+
+	label := fmt.Sprintf("%.0f", top)
+	margin := len(label) + 1
+
+	var lines []string
+	for row := graphHeight - 1; row >= 0; row-- {
+		prefix := strings.Repeat(" ", margin)
+		if row == graphHeight-1 {
+			prefix = label + " "
+		} else if row == 0 {
+			prefix = fmt.Sprintf("%*v ", len(label), 0)
+		}
+
+		line := ""
+		for _, s := range samples {
+			// Height of the bar in eighths of a row, above the bottom of this row.
+			eighths := int(s.WPM/top*float64(graphHeight*len(graphBlocks))+0.5) - row*len(graphBlocks)
+			switch {
+			case eighths <= 0:
+				line += " "
+			case eighths >= len(graphBlocks):
+				line += string(graphBlocks[len(graphBlocks)-1])
+			default:
+				line += string(graphBlocks[eighths-1])
+			}
+		}
+
+		lines = append(lines, prefix+m.settings.theme.typed.Render(line))
+	}
+
+	mistakes := ""
+	for _, s := range samples {
+		if s.Mistakes > 0 {
+			mistakes += "x"
+		} else {
+			mistakes += " "
+		}
+	}
+
+	lines = append(lines, strings.Repeat(" ", margin)+m.settings.theme.mistake.Render(mistakes))
+	lines = append(lines, fmt.Sprintf("%v0s%*vs", strings.Repeat(" ", margin), max(len(samples)-3, 1), samples[len(samples)-1].Second))
+
+	return "WPM over time\n\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nicdgonzalez/typing-tui/engine"
+	"github.com/rivo/uniseg"
+)
+
+// Colors of the heat map, from words typed well to words typed badly.
+var heatColors = [][3]float64{
+	{0x22, 0xc5, 0x5e}, // Green
+	{0xea, 0xb3, 0x08}, // Yellow
+	{0xef, 0x44, 0x44}, // Red
+}
+
+// Represents how well a single word of the prompt was typed.
+type wordHeat struct {
+	text   string  // Word the prompt asked for
+	errors float64 // Share of characters typed incorrectly at least once
+	wpm    float64 // Speed the word was typed at, or 0 if unknown
+}
+
+// Get how well every word the user reached was typed.
+func (m Model) wordHeats() []wordHeat {
+	speeds := make(map[int]float64)
+	for _, w := range m.recorder.wordReports() {
+		speeds[w.Index] = w.WPM
+	}
+
+	var heats []wordHeat
+	var word []engine.Cell
+
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+
+		h := wordHeat{wpm: speeds[len(heats)]}
+		wrong := 0
+		for _, cell := range word {
+			h.text += cell.Expected
+			if cell.Errors > 0 || cell.State == engine.SKIPPED {
+				wrong++
+			}
+		}
+
+		h.errors = float64(wrong) / float64(len(word))
+		heats = append(heats, h)
+		word = nil
+	}
+
+	for _, cell := range m.test.Cells()[:m.test.Cursor()] {
+		if cell.Space() {
+			flush()
+		} else {
+			word = append(word, cell)
+		}
+	}
+
+	flush()
+	return heats
+}
+
+// Score how badly a word was typed, from 0 to 1: the worse of its error rate
+// and how far below the average speed it was typed.
+func (h wordHeat) badness(average float64) float64 {
+	slowness := 0.0
+	if h.wpm > 0 && average > 0 {
+		slowness = min(max((average-h.wpm)/average, 0), 1)
+	}
+
+	return max(h.errors, slowness)
+}
+
+// Get the color of a badness score along the heat map's gradient.
+func heatColor(badness float64) lipgloss.Color {
+	segments := float64(len(heatColors) - 1)
+	i := min(int(badness*segments), len(heatColors)-2)
+	t := badness*segments - float64(i)
+
+	var rgb [3]int
+	for c := range rgb {
+		from, to := heatColors[i][c], heatColors[i+1][c]
+		rgb[c] = int(from + (to-from)*t)
+	}
+
+	return lipgloss.Color(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+}
+
+// Render the words the user typed, each colored by how well it was typed, so
+// problem areas stand out.
+func (m Model) heatmapView() string {
+	heats := m.wordHeats()
+	if len(heats) == 0 {
+		return ""
+	}
+
+	average := m.test.Score().WPM
+
+	var lines []string
+	line, width := "", 0
+	for _, h := range heats {
+		if width > 0 && width+uniseg.StringWidth(h.text) > terminalWidthDefault {
+			lines = append(lines, line)
+			line, width = "", 0
+		}
+
+		if width > 0 {
+			line += " "
+			width++
+		}
+
+		text := h.text
+		if m.settings.plain && h.badness(average) >= plainBadness {
+			text = "[" + text + "]"
+		}
+
+		line += lipgloss.NewStyle().Foreground(heatColor(h.badness(average))).Render(text)
+		width += uniseg.StringWidth(text)
+	}
+
+	lines = append(lines, line)
+	return m.direction.isolate(strings.Join(lines, "\n"))
+}
+
+// Spaces each row of the keyboard heat map is indented by, so the keys are
+// staggered the way they are on a keyboard.
+var keyboardIndents = [4]int{0, 4, 5, 7}
+
+// Get the layout the user types on, to find the keys of their mistakes.
+func (m Model) typingLayout() Layout {
+	for _, name := range []string{m.settings.learn, m.settings.layout} {
+		if l, err := lookupLayout(name); err == nil {
+			return l
+		}
+	}
+
+	l, _ := lookupLayout(keycapsDefault)
+	return l
+}
+
+// Count the keystrokes of the test on each key of a layout, by the character
+// the prompt asked for. Characters that aren't on the layout's main rows,
+// like the space, are left out.
+func (k *keyLog) keyStats(l Layout) map[[2]int]charStat {
+	stats := make(map[[2]int]charStat)
+	for c, stat := range k.stats {
+		r := []rune(c)
+		if len(r) != 1 {
+			continue
+		}
+
+		row, col, ok := l.position(r[0])
+		if !ok {
+			continue
+		}
+
+		key := stats[[2]int{row, col}]
+		key.attempts += stat.attempts
+		key.misses += stat.misses
+		stats[[2]int{row, col}] = key
+	}
+
+	return stats
+}
+
+// Render the keyboard, each key colored by how often it was missed compared
+// to the key missed most, so the keys to practice stand out. Keys that
+// weren't typed are left uncolored. Nothing is drawn without mistakes.
+func (m Model) keyboardView() string {
+	l := m.typingLayout()
+	stats := m.keys.keyStats(l)
+
+	worst := 0.0
+	for _, stat := range stats {
+		worst = max(worst, float64(stat.misses)/float64(stat.attempts))
+	}
+
+	if worst == 0 {
+		return ""
+	}
+
+	lines := make([]string, len(l.rows))
+	for row, keys := range l.rows {
+		line := strings.Repeat(" ", keyboardIndents[row])
+		for col, key := range []rune(keys) {
+			label := " " + strings.ToUpper(string(key)) + " "
+			stat, ok := stats[[2]int{row, col}]
+			if !ok {
+				line += m.settings.theme.prompt.Render(label)
+				continue
+			}
+
+			badness := float64(stat.misses) / float64(stat.attempts) / worst
+			if m.settings.plain && badness >= plainBadness {
+				label = "[" + strings.TrimSpace(label) + "]"
+			}
+
+			line += lipgloss.NewStyle().
+				Background(heatColor(badness)).
+				Foreground(lipgloss.Color("#000000")).
+				Render(label)
+		}
+
+		lines[row] = line
+	}
+
+	return strings.Join(lines, "\n")
+}
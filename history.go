@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Name of the file that stores every completed test, one JSON object per line.
+const historyFile = "history.jsonl"
+
+// Version of the formulas results are scored with. Raise it whenever they
+// change, so `history rescore` knows which results to bring up to date.
+const scoringVersion = 1
+
+// Represents the outcome of a completed test.
+type Result struct {
+	Time       time.Time     `json:"time"`                 // When the test was finished
+	Mode       string        `json:"mode"`                 // Kind of test, e.g. "time"
+	Duration   int           `json:"duration"`             // Time limit in seconds
+	Language   string        `json:"language"`             // Name of the word list
+	Difficulty string        `json:"difficulty"`           // Difficulty setting, e.g. "normal"
+	Elapsed    float64       `json:"elapsed"`              // Seconds spent typing
+	WPM        float64       `json:"wpm"`                  // Words per minute, excluding mistakes
+	Raw        float64       `json:"raw"`                  // Words per minute, including mistakes
+	Accuracy   float64       `json:"accuracy"`             // Percentage of correct keystrokes
+	Correct    int           `json:"correct"`              // Counter for correct keystrokes
+	Mistakes   int           `json:"mistakes"`             // Counter for typos
+	Level      int           `json:"level,omitempty"`      // Difficulty level in auto mode
+	Layout     string        `json:"layout,omitempty"`     // Keyboard layout the test was typed on
+	Keyboard   string        `json:"keyboard,omitempty"`   // Keyboard the test was typed on
+	Incomplete bool          `json:"incomplete,omitempty"` // Whether the user quit before the test was over
+	Failed     bool          `json:"failed,omitempty"`     // Whether a mistake failed the test in expert or master mode
+	Shifted    float64       `json:"shifted,omitempty"`    // Accuracy of the shifted symbols in number row drills
+	Scoring    int           `json:"scoring,omitempty"`    // Version of the formulas the result was scored with (0 for before they were versioned)
+	Settings   *TestSettings `json:"settings,omitempty"`   // Every setting in effect, or nil for results saved before they were recorded
+}
+
+// Represents the settings a test was taken with, so results can be compared
+// knowing what rules were in effect.
+type TestSettings struct {
+	Mode          string `json:"mode"`                     // Kind of test, e.g. "time"
+	Duration      int    `json:"duration"`                 // Time limit in seconds
+	Language      string `json:"language"`                 // Name of the word list
+	Source        string `json:"source,omitempty"`         // Where the words of the prompt came from
+	Words         int    `json:"words,omitempty"`          // Number of words in the prompt
+	Tier          int    `json:"tier,omitempty"`           // Only the most common words were picked from this many (0 for all)
+	Punctuation   bool   `json:"punctuation,omitempty"`    // Whether words could be followed by punctuation
+	Numbers       bool   `json:"numbers,omitempty"`        // Whether words could be replaced by numbers
+	Adaptive      bool   `json:"adaptive,omitempty"`       // Whether weak characters were practiced more often
+	Auto          bool   `json:"auto,omitempty"`           // Whether the difficulty was adjusted automatically
+	Level         int    `json:"level,omitempty"`          // Difficulty level in auto mode
+	Theme         string `json:"theme,omitempty"`          // Name of the theme
+	Layout        string `json:"layout,omitempty"`         // Keyboard layout the test was typed on
+	Keyboard      string `json:"keyboard,omitempty"`       // Keyboard the test was typed on
+	Learn         string `json:"learn,omitempty"`          // Layout the user was learning
+	Quote         string `json:"quote,omitempty"`          // Who said the quote typed in quote mode
+	ReducedMotion bool   `json:"reduced_motion,omitempty"` // Whether animations were skipped
+	ForgiveErased bool   `json:"forgive_erased,omitempty"` // Whether mistakes that were erased didn't count
+	MinWPM        int    `json:"min_wpm,omitempty"`        // Slowest speed allowed before the test was failed
+}
+
+// Get every result saved in dir, oldest first.
+func loadResults(dir string) ([]Result, error) {
+	file, err := os.Open(filepath.Join(dir, historyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	var results []Result
+	decoder := json.NewDecoder(file)
+	for {
+		var r Result
+		if err := decoder.Decode(&r); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse json: %v", err)
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// Get up to limit results saved in dir, newest first, skipping the offset
+// newest. Only the results on the page are kept in memory, however long the
+// history is.
+func loadPage(dir string, offset int, limit int) ([]Result, error) {
+	name := filepath.Join(dir, historyFile)
+
+	// Results are saved oldest first, so the file is read once to count them,
+	// and again to find the page.
+	total := 0
+	err := eachLine(name, func(json.RawMessage) error {
+		total++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	first := max(total-offset-limit, 0)
+	last := total - offset
+
+	var results []Result
+	i := 0
+	err = eachLine(name, func(line json.RawMessage) error {
+		if i >= first && i < last {
+			var r Result
+			if err := json.Unmarshal(line, &r); err != nil {
+				return fmt.Errorf("failed to parse json: %v", err)
+			}
+
+			results = append(results, r)
+		}
+
+		i++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(results)
+	return results, nil
+}
+
+// Call fn with every line of a JSON lines file, without parsing them. A
+// missing file has no lines.
+func eachLine(name string, fn func(json.RawMessage) error) error {
+	file, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	decoder := json.NewDecoder(file)
+	for {
+		var line json.RawMessage
+		if err := decoder.Decode(&line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to parse json: %v", err)
+		}
+
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+}
+
+// Add a result to the end of the history saved in dir.
+func saveResult(dir string, r Result) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	name := filepath.Join(dir, historyFile)
+	_, err = os.Stat(name)
+	created := errors.Is(err, os.ErrNotExist)
+
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// Make sure the result is on disk before moving on, in case the terminal
+	// is closed right after the test.
+	if err := file.Sync(); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// A new history starts out at the current version, so it never needs
+	// migrating.
+	if created {
+		return saveSchemaVersion(dir)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Number of words in a kids mode prompt.
+const kidsWordsDefault = 10
+
+// Longest word used in kids mode.
+const kidsMaxWordLength = 4
+
+// Number of words to pick from in kids mode.
+const kidsVocabulary = 50
+
+// Get the most common short, lowercase words from a list. The list is
+// expected to be sorted from most to least common.
+func kidsWords(words []string) []string {
+	var result []string
+	for _, w := range words {
+		if len(result) == kidsVocabulary {
+			break
+		}
+
+		if len(w) <= kidsMaxWordLength && w == strings.ToLower(w) {
+			result = append(result, w)
+		}
+	}
+
+	return result
+}
+
+// Convert ASCII characters to their full-width forms, which terminals draw
+// twice as wide as usual.
+func fullWidth(c rune) rune {
+	switch {
+	case c == ' ':
+		return '　'
+	case c >= '!' && c <= '~':
+		return c + 0xFEE0
+	default:
+		return c
+	}
+}
+
+// Get the start and end of the word the user is currently typing. When the
+// cursor is on a space, the word after it is used.
+func (m Model) activeWord() (int, int) {
+	cells := m.test.Cells()
+
+	start := m.test.Cursor()
+	if start < len(cells) && cells[start].Space() {
+		start++
+	}
+
+	for start > 0 && !cells[start-1].Space() {
+		start--
+	}
+
+	end := start
+	for end < len(cells) && !cells[end].Space() {
+		end++
+	}
+
+	return start, end
+}
+
+// Render the word the user is typing in large letters.
+func (m Model) kidsBanner() string {
+	cells := m.test.Cells()
+	start, end := m.activeWord()
+
+	s := ""
+	for i := start; i < end; i++ {
+		c := cells[i].Expected
+		if r := []rune(c); len(r) == 1 {
+			c = string(fullWidth(r[0]))
+		}
+
+		s += m.renderCell(i, c)
+	}
+
+	return s
+}
+
+// Render a cheerful summary of the test. Kids mode has no way to fail, so
+// every result earns at least one star.
+func (m Model) kidsStatsView() string {
+	r := m.result()
+
+	stars := 1
+	message := "Good try! Keep practicing!"
+
+	if r.Accuracy >= 95 {
+		stars = 3
+		message = "Amazing! You're a typing star!"
+	} else if r.Accuracy >= 80 {
+		stars = 2
+		message = "Great job!"
+	}
+
+	s := strings.Repeat("★ ", stars) + strings.Repeat("☆ ", 3-stars) + "\n\n"
+	s += message + "\n\n"
+	s += fmt.Sprintf(
+		"You typed %v words in %.0f seconds!\n",
+		len(strings.Fields(m.test.Prompt())),
+		r.Elapsed,
+	)
+
+	return s
+}
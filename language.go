@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Names of the files inside a language's folder in the words directory.
+const (
+	manifestFile  = "manifest.toml"
+	wordsFile     = "words.json"
+	sentencesFile = "sentences.json"
+)
+
+// Represents the direction a language is written in.
+type Direction int16
+
+const (
+	LTR Direction = iota // Left to right
+	RTL                  // Right to left
+)
+
+// Every direction, in the order they are listed to the user.
+var directions = []Direction{LTR, RTL}
+
+// Get the name of a direction, as used in manifests.
+func (d Direction) String() string {
+	switch d {
+	case RTL:
+		return "rtl"
+	default:
+		return "ltr"
+	}
+}
+
+// Get a direction from its name.
+func parseDirection(name string) (Direction, error) {
+	for _, d := range directions {
+		if d.String() == name {
+			return d, nil
+		}
+	}
+
+	return LTR, fmt.Errorf("unknown direction: %v", name)
+}
+
+// Allows the direction to be read from a manifest by name.
+func (d *Direction) UnmarshalText(text []byte) error {
+	direction, err := parseDirection(string(text))
+	*d = direction
+	return err
+}
+
+// Mark every line of text as written in the direction, so terminals that
+// support bidirectional text lay it out correctly.
+func (d Direction) isolate(text string) string {
+	if d != RTL {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = "\u2067" + line + "\u2069" // Right-to-left isolate
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Represents what a language's manifest says about it.
+type Manifest struct {
+	Name      string    `toml:"name"`      // Name shown to the user, e.g. "Español"
+	Charset   string    `toml:"charset"`   // Characters the words are written with (empty for any)
+	Direction Direction `toml:"direction"` // Direction the language is written in
+}
+
+// Get the folder of a language that ships more than a word list.
+func languageDir(language string) string {
+	return filepath.Join(wordsDir, language)
+}
+
+// Report whether a language is a folder, rather than a single word list.
+func isLanguageDir(language string) bool {
+	info, err := os.Stat(languageDir(language))
+	return err == nil && info.IsDir()
+}
+
+// Get the manifest of a language. Languages without one are named after their
+// file and written left to right in any characters.
+func loadManifest(language string) (Manifest, error) {
+	manifest := Manifest{Name: language}
+	if !isLanguageDir(language) {
+		return manifest, nil
+	}
+
+	_, err := toml.DecodeFile(filepath.Join(languageDir(language), manifestFile), &manifest)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return manifest, fmt.Errorf("failed to parse %v manifest: %v", language, err)
+	}
+
+	return manifest, nil
+}
+
+// Get the name of a language to show the user, e.g. "Español (spanish)".
+func languageName(language string) string {
+	manifest, err := loadManifest(language)
+	if err != nil || manifest.Name == language {
+		return language
+	}
+
+	return fmt.Sprintf("%v (%v)", manifest.Name, language)
+}
+
+// Remove words with characters outside the charset, e.g. stray English words
+// in a list for another alphabet.
+func (m Manifest) filter(words []string) []string {
+	if m.Charset == "" {
+		return words
+	}
+
+	var kept []string
+	for _, w := range words {
+		if strings.Trim(w, m.Charset) == "" {
+			kept = append(kept, w)
+		}
+	}
+
+	return kept
+}
+
+// Get the direction of the prompt for every language in the settings. Mixing
+// languages written in different directions is shown left to right.
+func promptDirection(settings Settings) Direction {
+	if settings.builtinWords {
+		return LTR
+	}
+
+	for _, language := range strings.Split(settings.language, languageSeparator) {
+		manifest, err := loadManifest(language)
+		if err != nil || manifest.Direction != RTL {
+			return LTR
+		}
+	}
+
+	return RTL
+}
+
+// Get the sentences of every language in the settings.
+func loadSentences(settings Settings) ([]string, error) {
+	var lists [][]string
+	for _, language := range strings.Split(settings.language, languageSeparator) {
+		if !isLanguageDir(language) {
+			return nil, fmt.Errorf("%v has no sentences", language)
+		}
+
+		sentences, err := getWords(filepath.Join(languageDir(language), sentencesFile))
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, sentences)
+	}
+
+	return mixWords(lists), nil
+}
+
+// Pick random sentences until there are at least as many words as asked for.
+func pickSentences(sentences []string, words int) string {
+	picked := ""
+	for _, i := range rand.Perm(len(sentences)) {
+		picked = strings.TrimSpace(picked + " " + sentences[i])
+		if len(strings.Fields(picked)) >= words {
+			break
+		}
+	}
+
+	return picked
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Layout printed on most keyboards' keys, used for hints unless the user says
+// otherwise.
+const keycapsDefault = "qwerty"
+
+// Represents a keyboard layout by the characters on its main rows of keys,
+// from the number row down. Every layout has the same number of keys on each
+// row, so the same index refers to the same physical key.
+type Layout struct {
+	name string
+	rows [4]string
+}
+
+// Layouts the program knows about.
+var layouts = []Layout{
+	{"qwerty", [4]string{"`1234567890-=", "qwertyuiop[]", "asdfghjkl;'", "zxcvbnm,./"}},
+	{"dvorak", [4]string{"`1234567890[]", "',.pyfgcrl/=", "aoeuidhtns-", ";qjkxbmwvz"}},
+	{"colemak", [4]string{"`1234567890-=", "qwfpgjluy;[]", "arstdhneio'", "zxcvbkm,./"}},
+	{"qwertz", [4]string{"^1234567890ß´", "qwertzuiopü+", "asdfghjklöä", "yxcvbnm,.-"}},
+	{"azerty", [4]string{"²&é\"'(-è_çà)=", "azertyuiop^$", "qsdfghjklmù", "wxcvbn,;:!"}},
+}
+
+// Find the physical key that produces a character. Uppercase letters are
+// found on the same key as their lowercase form.
+func (l Layout) position(c rune) (int, int, bool) {
+	c = unicode.ToLower(c)
+	for row, keys := range l.rows {
+		for col, key := range []rune(keys) {
+			if key == c {
+				return row, col, true
+			}
+		}
+	}
+
+	return 0, 0, false
+}
+
+// Get the character produced by a physical key.
+func (l Layout) at(row int, col int) rune {
+	return []rune(l.rows[row])[col]
+}
+
+// Get the character that comes out when someone presses the key for c on
+// layout from, while the computer is set to layout to.
+func translate(from Layout, to Layout, c rune) (rune, bool) {
+	row, col, ok := from.position(c)
+	if !ok {
+		return 0, false
+	}
+
+	return to.at(row, col), true
+}
+
+// Get a layout by name.
+func lookupLayout(name string) (Layout, error) {
+	for _, l := range layouts {
+		if l.name == name {
+			return l, nil
+		}
+	}
+
+	names := make([]string, len(layouts))
+	for i, l := range layouts {
+		names[i] = l.name
+	}
+
+	return Layout{}, fmt.Errorf("unknown layout: %v (expected one of %v)", name, strings.Join(names, ", "))
+}
+
+// Describe which key to press for c on a keyboard whose keys are labeled
+// with another layout, e.g. "QWERTY key: J" for "n" on Colemak.
+func keyHint(learning Layout, keycaps Layout, c rune) (string, bool) {
+	key, ok := translate(learning, keycaps, c)
+	if !ok || c == ' ' {
+		return "", false
+	}
+
+	label := string(unicode.ToUpper(key))
+	if unicode.IsUpper(c) {
+		label = "Shift+" + label
+	}
+
+	return fmt.Sprintf("%v key: %v", strings.ToUpper(keycaps.name), label), true
+}
+
+// Render which key to press next, for users learning a new layout.
+func (m Model) hintView() string {
+	learning, err := lookupLayout(m.settings.learn)
+	if err != nil {
+		return ""
+	}
+
+	keycaps, err := lookupLayout(m.settings.keycaps)
+	if err != nil {
+		return ""
+	}
+
+	cells := m.test.Cells()
+	if m.test.Cursor() >= len(cells) {
+		return ""
+	}
+
+	hint, _ := keyHint(learning, keycaps, []rune(cells[m.test.Cursor()].Expected)[0])
+	return hint
+}
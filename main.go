@@ -1,150 +1,1475 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
+	"github.com/nicdgonzalez/typing-tui/engine"
+	"github.com/rivo/uniseg"
 )
 
-// Represents the user's current action.
-type State int16
+// Represents the contents being displayed to the user.
+type View int16
+
+const (
+	PROMPT    View = iota // Typing test
+	STATS                 // Calculated statistics
+	RECORDS               // Personal bests
+	LOGIN                 // User switching
+	MENU                  // Choices made before the test
+	WORDS                 // Word list couldn't be loaded
+	HISTORY               // Every saved result, a page at a time
+	WEAKSPOTS             // Slowest and least accurate things the user types
+	REPLAY                // Finished test played back key by key
+	RESUME                // Offer to pick up a session that was cut short
+)
+
+// Represents the kind of test being taken.
+type Mode int16
+
+const (
+	TIME       Mode = iota // Type as much as possible before the time runs out
+	KIDS                   // Short words, large text, and no time limit or way to fail
+	STOPWATCH              // Type the whole prompt while the time counts up
+	WORD_COUNT             // Type a chosen number of words as fast as possible
+	QUOTE                  // Type a whole quote from the built-in collection
+	ZEN                    // Type anything without a prompt until ESC is pressed
+	TRANSCRIBE             // Copy text shown in its own pane, scored once it's done
+	CODE                   // Type code line by line, keeping its line breaks and indentation
+)
+
+// Every mode, in the order they are listed to the user.
+var modes = []Mode{TIME, WORD_COUNT, QUOTE, TRANSCRIBE, CODE, ZEN, STOPWATCH, KIDS}
+
+// Get the name of a mode, as used on the command line and in saved results.
+func (mode Mode) String() string {
+	switch mode {
+	case KIDS:
+		return "kids"
+	case STOPWATCH:
+		return "stopwatch"
+	case WORD_COUNT:
+		return "words"
+	case QUOTE:
+		return "quote"
+	case ZEN:
+		return "zen"
+	case TRANSCRIBE:
+		return "transcribe"
+	case CODE:
+		return "code"
+	default:
+		return "time"
+	}
+}
+
+// Get a mode from its name.
+func parseMode(name string) (Mode, error) {
+	for _, mode := range modes {
+		if mode.String() == name {
+			return mode, nil
+		}
+	}
+
+	return TIME, fmt.Errorf("unknown mode: %v", name)
+}
+
+// Allows the mode to be read from the config file by name.
+func (mode *Mode) UnmarshalText(text []byte) error {
+	m, err := parseMode(string(text))
+	*mode = m
+	return err
+}
+
+// Allows the mode to be written to the config file by name.
+func (mode Mode) MarshalText() ([]byte, error) {
+	return []byte(mode.String()), nil
+}
+
+type tickMsg time.Time
+
+// How often the clock ticks, to update the timer and end timed tests.
+var tickInterval = time.Second
+
+// Default settings
+var (
+	terminalWidthDefault = 70
+	timeLimitDefault     = 30
+	languageDefault      = "english"
+	promptWordsDefault   = 50
+	wordCountDefault     = 25
+	focusDefault         = 3
+)
+
+// Represents options chosen by the user before the test starts.
+type Settings struct {
+	mode           Mode                // Kind of test to take
+	duration       int                 // Time limit in seconds, instead of asking in the menu (0 to ask)
+	words          int                 // Number of words in word count mode, instead of asking in the menu (0 to ask)
+	tier           int                 // Only pick from this many of the most common words (0 for all)
+	quoteLength    QuoteLength         // Length of the quotes picked in quote mode
+	language       string              // Name of the word list
+	builtinWords   bool                // Use the word list built into the program
+	text           string              // Text to type instead of a generated prompt
+	chunks         []string            // Parts of the text, typed one test at a time (empty to type it all at once)
+	chunk          int                 // Index of the part being typed
+	playlist       *playlist           // Tests being taken one after another, or nil
+	source         Source              // Where the words of the prompt come from
+	punctuation    bool                // Follow some words with punctuation
+	numbers        bool                // Replace some words with random numbers
+	keepTypography bool                // Leave curly quotes, dashes, and the like in text as they are
+	filterRepeats  bool                // Ignore keys repeated by holding them down
+	combo          bool                // Show an arcade score that rewards long runs of correct characters
+	feeds          []string            // URLs of the news feeds used by the RSS source
+	theme          Theme               // Styles used to draw the prompt
+	themeName      string              // Name of the theme, before the config file's colors are applied
+	configDir      string              // Directory the config file is read from
+	timer          TimerDisplay        // How the timer is shown during the test
+	layout         string              // Name of the user's keyboard layout
+	keyboard       string              // Name of the user's keyboard
+	learn          string              // Name of the layout the user is learning, to show key hints for
+	keycaps        string              // Name of the layout printed on the user's keys
+	adaptive       bool                // Practice weak characters more often
+	auto           bool                // Adjust the difficulty based on recent results
+	records        bool                // Show personal bests instead of starting a test
+	history        bool                // Browse saved results instead of starting a test
+	weakSpots      bool                // Show the user's weak spots instead of starting a test
+	drill          []string            // Every word of the prompt contains one of these (empty for any word)
+	users          bool                // Ask who is typing before each test
+	noDistractions bool                // Keep the speed, accuracy, and score out of sight until the test is over
+	reducedMotion  bool                // Skip animations
+	remote         bool                // Tune for a slow connection, e.g. over SSH
+	plain          bool                // Draw without colors, marking mistakes and the cursor with text
+	caret          CaretStyle          // How the character the user is on is marked
+	alert          Alert               // How the user is told the time ran out
+	difficulty     Difficulty          // How mistakes are dealt with
+	forgiveErased  bool                // Don't count mistakes that were erased against the score
+	minWPM         int                 // Fail the test if the speed drops below this (0 for no minimum)
+	snippets       string              // Programming language of the snippets typed in code mode
+	fullPrompt     bool                // Show the whole prompt instead of a few lines at a time
+	bigText        bool                // Show the word being typed in large letters above the prompt
+	storage        Backend             // Where results are saved
+	retention      Retention           // How much history to keep
+	budget         Budget              // How much time the user means to practice
+	onQuit         QuitAction          // What happens when the user quits mid-test
+	confirmQuit    bool                // Ask before quitting mid-test
+	report         string              // File to write a detailed report of each test to
+	anonymize      bool                // Leave the text of the prompt out of reports
+	presets        map[string]Preset   // Named sets of settings the user can start from the menu
+	providers      map[string][]string // Commands that write drills, by name
+	provider       string              // Name of the drill provider to get the prompt from (empty for none)
+	demo           bool                // Have a simulated typist type the tests instead of the user
+	autosave       bool                // Write down the session as it goes, so it can be resumed after a crash
+	demoWPM        int                 // Speed the simulated typist types at, in words per minute
+}
+
+// Represents the application's state.
+type Model struct {
+	test        *engine.Engine // Typing test being taken
+	keys        *keyLog        // Keystrokes of the test, as scored by the engine
+	demo        *engine.Typist // Types the test in demo mode, or nil
+	recorder    *recorder      // Events of the test, for its report
+	replay      *replay        // Test being played back on the replay screen, or nil
+	resumable   *session       // Session left behind the last time the program stopped, or nil
+	autosaved   time.Time      // When the session was last written down
+	terminated  bool           // Whether the program was told to stop from outside
+	combo       *combo         // Arcade score of the test
+	level       int            // Difficulty level in auto mode (0 when off)
+	prompt      promptConfig   // How the prompt was generated
+	quote       *Quote         // Quote being typed in quote mode, or nil
+	reference   string         // Text being copied in transcription mode
+	aligned     alignment      // How the transcription lined up with the text, once it's done
+	mode        Mode           // Kind of test being taken
+	language    string         // Name of the word list
+	direction   Direction      // Direction the prompt is written in
+	records     []Result       // Personal bests, shown on the records screen
+	settings    Settings       // Options the program was started with
+	home        string         // Directory shared by every user
+	dir         string         // Directory where the current user's stats are stored
+	store       Store          // Where the current user's results are saved
+	user        string         // Name of the current user in multi-user mode
+	login       loginForm      // State of the user-switch screen
+	menu        menuState      // State of the pre-test menu
+	dashboard   dashboard      // Summary of recent practice, shown on the menu
+	ledger      ledger         // Time spent practicing today and this week
+	missing     wordsError     // Why the word list couldn't be loaded
+	history     historyPage    // State of the history screen
+	weakSpots   weakSpotsPage  // State of the weak spots screen
+	watch       *watcher       // Files reloaded between tests when they change
+	toast       string         // Short notice shown on the menu and prompt
+	toastLeft   int            // Ticks before the notice disappears
+	confirm     bool           // Whether the user needs to decide if the result is kept
+	discarded   bool           // Whether the user chose not to keep the result
+	incomplete  bool           // Whether the user quit before the test was over
+	quitPressed time.Time      // When ESC or ctrl+c was last pressed mid-test
+	repeats     repeatFilter   // Keys repeated by holding them down
+	pb          bool           // Whether the result is a new personal best
+	previousPB  float64        // Speed of the personal best the result beat
+	confetti    []particle     // Pieces of the personal best animation
+	frame       int            // Current frame of the personal best animation
+	view        View           // Current display
+	width       int            // Width of the terminal, once it's known (0 until then)
+	height      int            // Height of the terminal, once it's known (0 until then)
+	err         error          // Problem to report on the stats screen
+}
+
+// The main entry point to the program.
+func main() {
+	if err := applyDirFlags(os.Args[1:]); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	words, err := findWordsDir()
+	if err != nil {
+		log.Fatalf("failed to get words directory: %v", err)
+	}
+
+	wordsDir = words
+
+	dir, err := configDir()
+	if err != nil {
+		log.Fatalf("failed to get config directory: %v", err)
+	}
+
+	cfg, err := loadConfig(dir)
+	if err != nil {
+		fmt.Printf("failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+
+	settings := Settings{
+		language:       languageDefault,
+		snippets:       snippetsDefault,
+		fullPrompt:     cfg.FullPrompt,
+		bigText:        cfg.BigText,
+		themeName:      themeDefault,
+		configDir:      dir,
+		feeds:          cfg.Feeds,
+		theme:          themes[themeDefault],
+		timer:          cfg.Timer,
+		layout:         cfg.Layout,
+		keyboard:       cfg.Keyboard,
+		learn:          cfg.Learn,
+		keycaps:        cfg.Keycaps,
+		noDistractions: cfg.NoDistractions,
+		reducedMotion:  cfg.ReducedMotion,
+		remote:         cfg.Remote,
+		plain:          cfg.Plain || dumbTerminal(),
+		caret:          cfg.Caret,
+		alert:          cfg.Alert,
+		difficulty:     cfg.Difficulty,
+		forgiveErased:  cfg.ForgiveErased,
+		minWPM:         cfg.MinWPM,
+		mode:           cfg.Mode,
+		duration:       cfg.Duration,
+		words:          cfg.Words,
+		tier:           cfg.Tier,
+		source:         cfg.Source,
+		punctuation:    cfg.Punctuation,
+		numbers:        cfg.Numbers,
+		adaptive:       cfg.Adaptive,
+		auto:           cfg.Auto,
+		storage:        cfg.Storage,
+		retention:      cfg.History,
+		budget:         cfg.Budget,
+		onQuit:         cfg.OnQuit,
+		confirmQuit:    cfg.ConfirmQuit,
+		presets:        cfg.Presets,
+		providers:      cfg.Providers,
+		keepTypography: cfg.KeepTypography,
+		filterRepeats:  cfg.FilterRepeats,
+		combo:          cfg.Combo,
+		quoteLength:    cfg.QuoteLength,
+		demoWPM:        demoWPMDefault,
+	}
+
+	if len(cfg.Languages) > 0 {
+		settings.language = strings.Join(cfg.Languages, languageSeparator)
+	}
+
+	if cfg.Theme != "" {
+		settings.theme = themes[cfg.Theme]
+		settings.themeName = cfg.Theme
+	}
+
+	if cfg.Snippets != "" {
+		settings.snippets = cfg.Snippets
+	}
+	modeNames := make([]string, len(modes))
+	for i, mode := range modes {
+		modeNames[i] = mode.String()
+	}
+
+	modeUsage := fmt.Sprintf("kind of test to take: %v (default %v)", strings.Join(modeNames, ", "), settings.mode)
+	flag.Func("mode", modeUsage, func(s string) error {
+		mode, err := parseMode(s)
+		settings.mode = mode
+		return err
+	})
+	themeUsage := fmt.Sprintf("colors to draw the prompt with: %v (default %v)", strings.Join(themeNames(), ", "), settings.themeName)
+	flag.Func("theme", themeUsage, func(s string) error {
+		theme, err := lookupTheme(s)
+		settings.theme = theme
+		settings.themeName = s
+		return err
+	})
+	caretNames := make([]string, len(caretStyles))
+	for i, c := range caretStyles {
+		caretNames[i] = c.String()
+	}
+
+	caretUsage := fmt.Sprintf("how to mark the character you're on: %v (default %v)", strings.Join(caretNames, ", "), settings.caret)
+	flag.Func("caret", caretUsage, func(s string) error {
+		caret, err := parseCaretStyle(s)
+		settings.caret = caret
+		return err
+	})
+	snippetsUsage := fmt.Sprintf("programming language of the snippets typed in code mode: %v (default %v)", strings.Join(snippetLanguages(), ", "), settings.snippets)
+	flag.Func("snippets", snippetsUsage, func(s string) error {
+		settings.snippets = s
+		return checkSnippets(s)
+	})
+	alertNames := make([]string, len(alerts))
+	for i, a := range alerts {
+		alertNames[i] = a.String()
+	}
+
+	difficultyNames := make([]string, len(difficulties))
+	for i, d := range difficulties {
+		difficultyNames[i] = d.String()
+	}
+
+	difficultyUsage := fmt.Sprintf("how mistakes are dealt with: %v (default %v)", strings.Join(difficultyNames, ", "), settings.difficulty)
+	flag.Func("difficulty", difficultyUsage, func(s string) error {
+		difficulty, err := parseDifficulty(s)
+		settings.difficulty = difficulty
+		return err
+	})
+	flag.BoolVar(&settings.forgiveErased, "forgive-erased", settings.forgiveErased, "don't count mistakes you erase against your accuracy")
+	alertUsage := fmt.Sprintf("how to tell you the time ran out: %v (default %v)", strings.Join(alertNames, ", "), settings.alert)
+	flag.Func("alert", alertUsage, func(s string) error {
+		alert, err := parseAlert(s)
+		settings.alert = alert
+		return err
+	})
+	timerUsage := fmt.Sprintf("how to show the timer: remaining, elapsed, both, or hidden (default %v)", settings.timer)
+	flag.Func("timer", timerUsage, func(s string) error {
+		timer, err := parseTimerDisplay(s)
+		settings.timer = timer
+		return err
+	})
+	timeUsage := fmt.Sprintf("time limit in seconds, e.g. 15, 30, 60, or 120 (up to %v), instead of picking it from the menu", maxDuration)
+	flag.Func("time", timeUsage, func(s string) error {
+		seconds, err := strconv.Atoi(s)
+		if err != nil || seconds < 1 || seconds > maxDuration {
+			return fmt.Errorf("invalid time limit: %v (expected 1 to %v seconds)", s, maxDuration)
+		}
+
+		settings.duration = seconds
+		return nil
+	})
+	flag.Func("min-wpm", "fail the test if your speed drops below this many words per minute (default no minimum)", func(s string) error {
+		wpm, err := strconv.Atoi(s)
+		if err != nil || wpm < 0 {
+			return fmt.Errorf("invalid minimum speed: %v (expected 0 or more words per minute)", s)
+		}
+
+		settings.minWPM = wpm
+		return nil
+	})
+	flag.Func("tier", "only pick from this many of the most common words, e.g. 200, 1000, or 10000, favoring the most common (default all)", func(s string) error {
+		tier, err := strconv.Atoi(s)
+		if err != nil || tier < 0 {
+			return fmt.Errorf("invalid tier: %v (expected 0 or more words)", s)
+		}
+
+		settings.tier = tier
+		return nil
+	})
+	flag.StringVar(&settings.language, "language", settings.language, "word list to pick words from; join several with + to mix them (e.g. english+spanish)")
+	flag.StringVar(&settings.text, "text", settings.text, "exact text to type instead of random words")
+	sourceNames := make([]string, len(sources))
+	for i, source := range sources {
+		sourceNames[i] = source.String()
+	}
+
+	sourceUsage := fmt.Sprintf("where the prompt comes from: %v (default %v)", strings.Join(sourceNames, ", "), settings.source)
+	flag.Func("source", sourceUsage, func(s string) error {
+		source, err := parseSource(s)
+		settings.source = source
+		return err
+	})
+	file := ""
+	flag.StringVar(&file, "file", file, "type the text in this file instead of random words (- for stdin)")
+	chunk := 0
+	flag.IntVar(&chunk, "chunk", chunk, "split the text into parts of this many words, typed one test at a time")
+	clipboard := false
+	flag.BoolVar(&clipboard, "clipboard", clipboard, "type the text on the clipboard instead of random words")
+	flag.StringVar(&settings.layout, "layout", settings.layout, "keyboard layout you type on, saved with results (e.g. qwerty)")
+	flag.StringVar(&settings.learn, "learn", settings.learn, "layout you are learning, to show which key to press on your keyboard (e.g. colemak)")
+	flag.StringVar(&settings.keyboard, "keyboard", settings.keyboard, "keyboard you type on, saved with results")
+	background := "auto"
+	flag.StringVar(&background, "background", background, "terminal background, to pick theme colors for: auto, light, or dark")
+	flag.BoolVar(&settings.adaptive, "adaptive", settings.adaptive, "practice weak characters more often")
+	flag.BoolVar(&settings.auto, "auto", settings.auto, "adjust the difficulty based on recent results")
+	flag.BoolVar(&settings.records, "records", false, "show personal bests")
+	flag.BoolVar(&settings.history, "history", false, "browse every saved result, newest first")
+	flag.BoolVar(&settings.weakSpots, "weak-spots", false, "show your slowest bigrams, most missed characters, worst words, and weakest fingers, and drill them")
+	flag.BoolVar(&settings.users, "users", false, "ask who is typing before each test, keeping stats separate")
+	flag.BoolVar(&settings.noDistractions, "no-distractions", settings.noDistractions, "keep your speed, accuracy, and score out of sight until the test is over")
+	flag.BoolVar(&settings.reducedMotion, "reduced-motion", settings.reducedMotion, "skip animations")
+	flag.BoolVar(&settings.remote, "remote", settings.remote, "tick less often, skip animations, and batch redraws, for slow connections like SSH")
+	flag.BoolVar(&settings.bigText, "big-text", settings.bigText, "show the word you're typing in large letters above the prompt, for reading from afar")
+	flag.BoolVar(&settings.plain, "plain", settings.plain, "draw without colors, marking mistakes with brackets (default on when TERM=dumb)")
+	flag.StringVar(&settings.report, "report", settings.report, "write a detailed JSON report of each test to this file")
+	flag.BoolVar(&settings.anonymize, "anonymize", settings.anonymize, "leave the text of the prompt out of reports, keeping only what kind of character each one is")
+	flag.BoolVar(&settings.punctuation, "punctuation", settings.punctuation, "follow some words with punctuation, capitalize sentences, and quote some words")
+	flag.BoolVar(&settings.numbers, "numbers", settings.numbers, "replace some words with random numbers")
+	flag.BoolVar(&settings.keepTypography, "keep-typography", settings.keepTypography, "leave curly quotes, dashes, and the like in text instead of replacing them with plain ones")
+	flag.BoolVar(&settings.filterRepeats, "filter-repeats", settings.filterRepeats, "ignore keys repeated by holding them down")
+	flag.BoolVar(&settings.combo, "combo", settings.combo, "show an arcade score that rewards long runs of correct characters")
+	lengthNames := make([]string, len(quoteLengths))
+	for i, length := range quoteLengths {
+		lengthNames[i] = length.String()
+	}
+
+	lengthUsage := fmt.Sprintf("length of the quotes picked in quote mode: %v (default %v)", strings.Join(lengthNames, ", "), settings.quoteLength)
+	flag.Func("quote-length", lengthUsage, func(s string) error {
+		length, err := parseQuoteLength(s)
+		settings.quoteLength = length
+		return err
+	})
+	playlistFile := ""
+	flag.StringVar(&playlistFile, "playlist", playlistFile, "take the tests listed in this file one after another")
+	preset := ""
+	flag.StringVar(&preset, "preset", preset, "named set of settings from the config file to start with")
+	flag.StringVar(&settings.provider, "provider", settings.provider, "drill provider from the config file to get the prompt from, written from your weak spots")
+	flag.BoolVar(&settings.demo, "demo", settings.demo, "watch a simulated typist take tests one after another, without saving them, until a key is pressed")
+	flag.Func("demo-wpm", fmt.Sprintf("speed the simulated typist types at in demo mode, in words per minute (default %v)", settings.demoWPM), func(s string) error {
+		wpm, err := strconv.Atoi(s)
+		if err != nil || wpm < 1 {
+			return fmt.Errorf("invalid speed: %v (expected 1 or more words per minute)", s)
+		}
+
+		settings.demoWPM = wpm
+		return nil
+	})
+	for _, f := range dirFlags {
+		flag.String(f.name, os.Getenv(f.env), f.usage)
+	}
+
+	logFile := ""
+	flag.StringVar(&logFile, "log", logFile, "write every update to this file, for debugging")
+	overlayAddr := cfg.Overlay
+	flag.StringVar(&overlayAddr, "overlay", overlayAddr, "serve the live state of each test over WebSocket on this port or address, for stream overlays (e.g. 8765)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// The run command starts a test, the same as no command at all, so the
+	// flags after it are parsed along with the ones before it.
+	args := os.Args[1:]
+	if flag.NArg() > 0 && flag.Arg(0) == "run" {
+		args = append(args[:len(args)-flag.NArg()], flag.Args()[1:]...)
+		flag.CommandLine.Parse(args)
+	}
+
+	// Flags given alongside a preset take priority over it, so they are
+	// parsed again once the preset is applied.
+	if preset != "" {
+		p, err := lookupPreset(settings.presets, preset)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+
+		settings = p.apply(settings)
+		flag.CommandLine.Parse(args)
+	}
+
+	if settings.provider != "" {
+		if _, err := lookupProvider(settings.providers, settings.provider); err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+	}
+
+	// The drill command runs a test like any other, so it's handled here
+	// rather than with the other commands.
+	var drill *editorDrill
+	if flag.NArg() > 0 && flag.Arg(0) == "drill" {
+		d, ok := parseEditorDrill(flag.Args()[1:])
+		if !ok {
+			os.Exit(2)
+		}
+
+		drill = &d
+	} else if flag.NArg() > 0 {
+		os.Exit(runCommand(flag.Args(), cfg))
+	}
+
+	// Text piped in is used the same way as a file given with --file.
+	piped := stdinPiped()
+	if file == "" && piped && settings.text == "" && !clipboard {
+		file = stdinName
+	}
+
+	if file != "" {
+		text, err := readTextFile(file)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		// With nothing to type, the prompt falls back to random words.
+		settings.text = text
+		if len(strings.Fields(settings.text)) == 0 {
+			settings.text = ""
+		}
+	}
+
+	if clipboard {
+		text, err := readClipboard()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		settings.text = text
+		if settings.text == "" {
+			fmt.Println("the clipboard is empty")
+			os.Exit(1)
+		}
+	}
+
+	if settings.mode == CODE {
+		settings.text = sanitizeCode(settings.text)
+	} else {
+		settings.text = sanitize(settings.text)
+	}
+
+	if settings.text != "" && len(strings.Fields(settings.text)) == 0 {
+		fmt.Println("text to type must contain at least one word")
+		os.Exit(2)
+	}
+
+	if chunk < 0 {
+		fmt.Println("chunk must be at least 1 word")
+		os.Exit(2)
+	}
+
+	// Code is typed in one go, since its lines don't split into words.
+	if chunk > 0 && settings.text != "" && settings.mode != CODE {
+		settings.chunks = splitChunks(settings.text, chunk)
+		settings.text = settings.chunks[0]
+	}
+
+	if playlistFile != "" {
+		p, err := loadPlaylist(playlistFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(2)
+		}
+
+		settings = startPlaylist(p, playlistFile, settings)
+	}
+
+	if drill != nil {
+		settings = drill.apply(settings)
+	}
+
+	// Only tests the user takes themselves are worth resuming after a crash.
+	settings.autosave = drill == nil && !settings.demo && !settings.records && !settings.history && !settings.weakSpots
+
+	if settings.keycaps == "" {
+		settings.keycaps = keycapsDefault
+	}
+
+	if settings.learn != "" {
+		for _, name := range []string{settings.learn, settings.keycaps} {
+			if _, err := lookupLayout(name); err != nil {
+				fmt.Println(err)
+				os.Exit(2)
+			}
+		}
+	}
+
+	// Colors aren't drawn in plain mode, so there's no need to ask the
+	// terminal which ones to use; terminals without colors can't answer.
+	if settings.plain {
+		usePlain()
+		settings.reducedMotion = true
+		if background == "auto" {
+			background = "dark"
+		}
+	}
+
+	if settings.remote {
+		settings = useRemote(settings)
+	}
+
+	if err := setBackground(background); err != nil {
+		fmt.Println(err)
+		os.Exit(2)
+	}
+
+	settings.theme = cfg.Colors.apply(settings.theme)
+
+	var observers []Observer
+	if logFile != "" {
+		f, err := tea.LogToFile(logFile, "")
+		if err != nil {
+			fmt.Printf("failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+
+		observers = append(observers, logTransitions)
+	}
+
+	if overlayAddr != "" {
+		server, err := startOverlay(overlayAddr, cfg.OverlayOrigins)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		observers = append(observers, server.observe)
+	}
+
+	options := []tea.ProgramOption{tea.WithoutSignalHandler()}
+	if !settings.plain {
+		options = append(options, tea.WithReportFocus())
+	}
+
+	if settings.remote {
+		options = append(options, tea.WithFPS(remoteFPS))
+	}
+
+	// Keys are read from the terminal itself when stdin is taken by the text.
+	if piped {
+		options = append(options, tea.WithInputTTY())
+	}
+
+	// An editor reading the drill's result from stdout can't show the program
+	// there too.
+	if drill != nil {
+		if tty := editorOutput(); tty != nil {
+			defer tty.Close()
+			options = append(options, tea.WithOutput(tty))
+			alertOutput = tty
+		}
+	}
+
+	p := tea.NewProgram(observe(initialModel(settings), observers...), options...)
+	handleSignals(p)
+	final, err := p.Run()
+	if err != nil {
+		fmt.Printf("an error occurred: %v", err)
+		os.Exit(1)
+	}
+
+	// A session the user quit on their own is over. One cut short is kept,
+	// and offered again the next time.
+	if m := unwrap(final); settings.autosave && !m.terminated && m.view != RESUME {
+		if err := clearSession(m.home); err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	if drill != nil {
+		os.Exit(drill.print(final))
+	}
+}
+
+func initialModel(settings Settings) Model {
+	home, err := dataDir()
+	if err != nil {
+		log.Fatalf("failed to get data directory: %v", err)
+	}
+
+	if settings.autosave {
+		if s, ok := loadSession(home); ok {
+			return Model{settings: settings, home: home, resumable: &s, view: RESUME}
+		}
+	}
+
+	return startModel(settings, home)
+}
+
+// Builds the first screen of a new session.
+func startModel(settings Settings, home string) Model {
+	if settings.users {
+		return loginModel(settings, home)
+	}
+
+	return newModel(settings, home, "")
+}
+
+// Builds the screen the program was started for. When name is given, the
+// stats of that user are used instead of the shared ones.
+func newModel(settings Settings, home string, name string) Model {
+	dir := home
+	if name != "" {
+		dir = userDir(home, name)
+	}
+
+	store, err := openStore(settings.storage, dir)
+	if err != nil {
+		log.Fatalf("failed to open history: %v", err)
+	}
+
+	if settings.records {
+		results, err := store.Load()
+		if err != nil {
+			log.Fatalf("failed to get history: %v", err)
+		}
+
+		return Model{
+			records:  personalBests(results),
+			settings: settings,
+			home:     home,
+			dir:      dir,
+			store:    store,
+			user:     name,
+			view:     RECORDS,
+		}
+	}
+
+	if settings.history {
+		return historyModel(settings, home, dir, store, name)
+	}
+
+	if settings.weakSpots {
+		return weakSpotsModel(settings, home, dir, store, name)
+	}
+
+	if settings.mode == ZEN {
+		return zenModel(settings, home, dir, store, name)
+	}
+
+	text, language := settings.text, settings.language
+	if text != "" {
+		language = textLanguage
+	}
+
+	notice := ""
+	if settings.mode == CODE {
+		if text != "" {
+			language = codeLanguage
+		} else if snippet, err := randomSnippet(settings.snippets); err != nil {
+			notice = fmt.Sprintf("Couldn't pick a snippet, so here are random words instead: %v", err)
+		} else {
+			text, language = snippet, settings.snippets+" code"
+		}
+	}
+	var quote *Quote
+	if settings.mode == QUOTE && text == "" {
+		q, err := randomQuote(settings.quoteLength)
+		if err != nil {
+			notice = fmt.Sprintf("Couldn't pick a quote, so here are random words instead: %v", err)
+		} else {
+			text, language, quote = q.Text, languageDefault, &q
+		}
+	} else if settings.provider != "" && text == "" {
+		generated, err := runProvider(settings, dir)
+		if err != nil {
+			notice = fmt.Sprintf("Couldn't get a drill from %v, so here are random words instead: %v", settings.provider, err)
+		} else {
+			text, language = generated, settings.provider
+		}
+	} else if settings.source == SENTENCES && text == "" {
+		sentences, err := loadSentences(settings)
+		if err != nil {
+			notice = fmt.Sprintf("Couldn't get sentences, so here are random words instead: %v", err)
+		} else {
+			text = pickSentences(sentences, promptWordsDefault)
+			language = fmt.Sprintf("%v sentences", settings.language)
+		}
+	} else if settings.source == NUMBER_ROW && text == "" {
+		language = numberRowLanguage
+	} else if settings.source != WORDLIST && text == "" {
+		fetched, err := fetchText(settings, home)
+		if err != nil {
+			notice = fmt.Sprintf("Couldn't get a prompt from %v, so here are random words instead: %v", settings.source, err)
+		} else {
+			text, language = fetched, settings.source.String()
+		}
+	}
+
+	if text != "" && !settings.keepTypography {
+		text = normalize(text)
+	}
+
+	var words []string
+	if text == "" && settings.source != NUMBER_ROW {
+		words, err = loadWords(settings)
+		if err != nil {
+			return wordsErrorModel(settings, home, name, err)
+		}
+	}
+
+	prefs, err := loadPreferences(dir)
+	if err != nil {
+		log.Fatalf("failed to get preferences: %v", err)
+	}
+
+	cfg := promptConfig{words: promptWordsDefault, tier: settings.tier, drill: settings.drill}
+	timeLimit := timeLimitDefault
+	if settings.duration > 0 {
+		timeLimit = settings.duration
+	} else if prefs.Duration > 0 {
+		timeLimit = prefs.Duration
+	}
+
+	if settings.punctuation {
+		cfg.punctuation = punctuationDefault
+	}
+
+	if settings.numbers {
+		cfg.numbers = numbersDefault
+	}
+
+	switch settings.mode {
+	case KIDS:
+		words = kidsWords(words)
+		cfg.words = kidsWordsDefault
+		timeLimit = 0
+	case STOPWATCH, QUOTE, TRANSCRIBE, CODE:
+		timeLimit = 0
+	case WORD_COUNT:
+		timeLimit = 0
+		cfg.words = wordCountDefault
+		if settings.words > 0 {
+			cfg.words = settings.words
+		} else if prefs.Words > 0 {
+			cfg.words = prefs.Words
+		}
+	}
+
+	if settings.adaptive {
+		p, err := loadProficiency(dir)
+		if err != nil {
+			log.Fatalf("failed to get proficiency: %v", err)
+		}
+
+		cfg.focus = p.due(time.Now(), focusDefault)
+	}
+
+	var lvl int
+	if settings.auto && settings.mode != KIDS {
+		results, err := store.Load()
+		if err != nil {
+			log.Fatalf("failed to get history: %v", err)
+		}
+
+		lvl = nextLevel(results)
+		l := levelSettings(lvl)
+		cfg.punctuation = l.punctuation
+		cfg.tier = l.tier
+
+		// The user picks the length of word count tests themselves.
+		if settings.mode != WORD_COUNT {
+			cfg.words = l.words
+		}
+	}
+
+	var prompt string
+	if settings.mode == CODE && text != "" {
+		prompt = text
+	} else if text != "" {
+		prompt = literalPrompt(text, timeLimit > 0, cfg.words)
+	} else if settings.source == NUMBER_ROW {
+		prompt = numberRowPrompt(cfg.words, cfg.rng)
+	} else if len(words) == 0 {
+		return wordsErrorModel(settings, home, name, errNoWords)
+	} else {
+		prompt = generatePrompt(words, cfg)
+	}
+
+	// In transcription mode, the prompt is shown apart from what's typed, so
+	// the test itself has no prompt.
+	test := engine.New(prompt, time.Duration(timeLimit)*time.Second, engine.SystemClock{})
+	test.SetForgive(settings.forgiveErased)
+	reference := ""
+	if settings.mode == TRANSCRIBE {
+		test = engine.NewFree(0, engine.SystemClock{})
+		reference = prompt
+	}
+
+	keys := newKeyLog()
+	test.Subscribe(keys.observe)
+	rec := &recorder{}
+	test.Subscribe(rec.observe)
+	score := &combo{}
+	test.Subscribe(score.observe)
+	for _, rule := range settings.rules() {
+		test.AddRule(rule)
+	}
+
+	// The demo has nobody to pick from the menu, so it starts right away.
+	view := PROMPT
+	if !settings.demo && ((settings.mode == TIME && settings.duration == 0) || (settings.mode == WORD_COUNT && settings.words == 0)) {
+		view = MENU
+	}
+
+	// The history is only read when there's a menu or a budget to show it on.
+	var dash dashboard
+	var spent ledger
+	if view == MENU || settings.budget.enabled() {
+		results, err := store.Load()
+		if err != nil {
+			log.Fatalf("failed to get history: %v", err)
+		}
+
+		dash = newDashboard(results, time.Now())
+		spent = newLedger(results, time.Now())
+	}
+
+	m := Model{
+		test:      test,
+		watch:     newWatcher(watchedFiles(settings)...),
+		keys:      keys,
+		direction: promptDirection(settings),
+		recorder:  rec,
+		combo:     score,
+		level:     lvl,
+		mode:      settings.mode,
+		language:  language,
+		settings:  settings,
+		home:      home,
+		dir:       dir,
+		store:     store,
+		user:      name,
+		menu:      newMenu(menuChoices(settings.mode), menuValue(settings.mode, timeLimit, cfg.words)),
+		prompt:    cfg,
+		quote:     quote,
+		reference: reference,
+		dashboard: dash,
+		ledger:    spent,
+		view:      view,
+	}
+
+	if settings.demo {
+		m.demo = newDemoTypist(settings.demoWPM)
+	}
+
+	if notice != "" {
+		m = m.notify(notice)
+	}
+
+	return m
+}
+
+// Get the words that will be used to construct the prompt.
+func getWords(name string) ([]string, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return parseWords(name, data)
+}
+
+// Runs once at the start of the application.
+func (m Model) Init() tea.Cmd {
+	if m.demo != nil {
+		return tea.Batch(tick(), demoKey(demoStart))
+	}
+
+	return tick() // Starts the internal clock.
+}
+
+// Ticks are used to represent time throughout the program.
+func tick() tea.Cmd {
+	return tea.Tick(tickInterval, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Manages the state of the application. A new model is made for every test,
+// so the size of the terminal is carried over to whichever model comes next.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		m.width, m.height = size.Width, size.Height
+		return m, nil
+	}
+
+	next, cmd := m.update(msg)
+	if n, ok := next.(Model); ok && n.width == 0 {
+		n.width, n.height = m.width, m.height
+		next = n
+	}
+
+	// The session is written down as soon as a test ends or its result is
+	// kept, rather than at the next tick.
+	if n, ok := next.(Model); ok && n.autosaving() && (n.view != m.view || n.confirm != m.confirm) {
+		next = n.autosave(time.Now())
+	}
+
+	return next, cmd
+}
+
+// Handles a message on the current screen.
+func (m Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(terminateMsg); ok {
+		return m.terminate()
+	}
+
+	if t, ok := msg.(tickMsg); ok {
+		m.toastLeft--
+		if m.idle() {
+			m = m.reload()
+		}
+
+		if m.autosaving() && time.Time(t).Sub(m.autosaved) >= autosaveInterval {
+			m = m.autosave(time.Time(t))
+		}
+	}
+
+	// The clock stops while the program is in the background, and starts
+	// again once it's brought back with fg.
+	if key, ok := msg.(tea.KeyMsg); ok && key.String() == "ctrl+z" {
+		if m.test != nil {
+			m.test.Pause()
+		}
+
+		return m, tea.Suspend
+	}
+
+	if _, ok := msg.(tea.ResumeMsg); ok && m.test != nil {
+		m.test.Resume()
+	}
+
+	if m.demo != nil && (m.view == PROMPT || m.view == STATS) {
+		return m.updateDemo(msg)
+	}
+
+	if m.view == LOGIN {
+		return m.updateLogin(msg)
+	}
+
+	if m.view == MENU {
+		return m.updateMenu(msg)
+	}
+
+	if m.view == WORDS {
+		return m.updateWordsError(msg)
+	}
+
+	if m.view == STATS {
+		return m.updateStats(msg)
+	}
+
+	if m.view == HISTORY {
+		return m.updateHistory(msg)
+	}
+
+	if m.view == WEAKSPOTS {
+		return m.updateWeakSpots(msg)
+	}
+
+	if m.view == REPLAY {
+		return m.updateReplay(msg)
+	}
+
+	if m.view == RESUME {
+		return m.updateResume(msg)
+	}
+
+	if m.view == RECORDS {
+		switch msg.(type) {
+		case tickMsg:
+			return m, tick()
+		case tea.KeyMsg:
+			if m.settings.users {
+				return loginModel(m.settings, m.home), nil
+			}
 
-const (
-	READY  State = iota // User can start typing
-	TYPING              // User is typing
-	DONE                // Test completed
-)
+			return m, tea.Quit
+		}
 
-// Represents the contents being displayed to the user.
-type View int16
+		return m, nil
+	}
 
-const (
-	PROMPT View = iota // Typing test
-	STATS              // Calculated statistics
-)
+	switch msg := msg.(type) {
+	case tickMsg:
+		if m.test.Expired() {
+			return m, tea.Batch(tick(), m.finish(), m.alert())
+		}
 
-type tickMsg time.Time
+		m.test.Tick()
+		if m.failed() {
+			return m, tea.Batch(tick(), m.finish())
+		}
 
-// Styles
-var (
-	promptStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#999999"))
-	mistakeStyle = lipgloss.NewStyle().Background(lipgloss.Color("#FF0000"))
-	cursorStyle  = lipgloss.NewStyle().Background(lipgloss.Color("#e2b714")).Foreground(lipgloss.Color("#000000"))
-)
+		return m, tick()
 
-// Default settings
-var (
-	terminalWidthDefault = 70
-	timeLimitDefault     = 30
-)
+	// Switching to another window shouldn't count against the user.
+	case tea.BlurMsg:
+		m.test.Pause()
 
-// Represents the application's state.
-type Model struct {
-	prompt     string // Randomly generated prompt
-	userInput  string // The characters that the user has typed
-	cursor     int    // User's position in the prompt
-	mistakes   int    // Counter for typos
-	charsTyped int    // Counter for characters typed
-	timePassed int    // Counter for seconds passed
-	timeLimit  int    // Time limit in seconds.
-	view       View   // Current display
-	state      State  // Current action
+	case tea.FocusMsg:
+		m.test.Resume()
+
+	case tea.KeyMsg:
+		// Ticks can come late, or far apart in remote mode, so keys pressed
+		// after the time ran out end the test instead of being counted.
+		if m.test.Expired() {
+			return m, tea.Batch(m.finish(), m.alert())
+		}
+
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			typing := m.test.State() == engine.TYPING
+
+			// Free typing has no end of its own, so ESC is how it's finished.
+			if typing && m.test.Free() && msg.String() == "esc" {
+				return m, m.finish()
+			}
+
+			// Ask for a second press, so a stray key doesn't end a good run.
+			if typing && m.settings.confirmQuit && time.Since(m.quitPressed) > quitWindow {
+				m.quitPressed = time.Now()
+				return m, nil
+			}
+
+			if !typing || msg.String() == "ctrl+c" || m.settings.onQuit == DISCARD_PARTIAL {
+				return m, tea.Quit
+			}
+
+			m.incomplete = true
+			return m, m.finish()
+
+		case "backspace":
+			m.test.Backspace()
+
+		// Most terminals send ctrl+backspace as ctrl+h, and some as
+		// alt+backspace.
+		case "ctrl+w", "ctrl+h", "alt+backspace":
+			m.test.BackspaceWord()
+
+		case "enter":
+			if m.mode != CODE {
+				break
+			}
+
+			if m.unforgiven("\n") {
+				break
+			}
+
+			m.typeLine()
+			if m.test.State() == engine.DONE {
+				return m, m.finish()
+			}
+
+		case "tab":
+			if m.mode == CODE && m.typeTab() {
+				if m.test.State() == engine.DONE {
+					return m, m.finish()
+				}
+
+				break
+			}
+
+			return m.reroll(), nil
+
+		default:
+			// Characters made of several code points, like most emoji,
+			// arrive together and are typed as one.
+			now := time.Now()
+			text := string(msg.Runes)
+
+			// Pasted text, and keys typed quickly over a slow connection,
+			// arrive all at once, so the same character twice in a row
+			// can't be told apart from a held key.
+			filter := m.settings.filterRepeats && !msg.Paste && uniseg.GraphemeClusterCount(text) == 1
+
+			g := uniseg.NewGraphemes(text)
+			for g.Next() {
+				if filter && m.repeats.repeated(g.Runes()[0], now) {
+					continue
+				}
+
+				if m.unforgiven(g.Str()) {
+					continue
+				}
+
+				m.test.TypeGrapheme(g.Str())
+				if m.failed() {
+					break
+				}
+			}
+
+			if m.test.State() == engine.DONE {
+				return m, m.finish()
+			}
+		}
+	}
+
+	return m, nil
 }
 
-// The main entry point to the program.
-func main() {
-	p := tea.NewProgram(initialModel())
-	if _, err := p.Run(); err != nil {
-		fmt.Printf("an error occurred: %v", err)
-		os.Exit(1)
+// Get a model with a new prompt in place of the current one, keeping the time
+// limit picked in the menu. Used to get a different prompt before starting, and
+// to start over mid-test or once the test is done.
+func (m Model) reroll() Model {
+	next := newModel(m.settings, m.home, m.user)
+	if next.view != MENU {
+		return next
 	}
+
+	next.test.SetLimit(m.test.Limit())
+	next.menu = m.menu
+	next.view = PROMPT
+	return next
 }
 
-func initialModel() Model {
-	words, err := getWords("words/english.json")
-	if err != nil {
-		log.Fatalf("failed to get words: %v", err)
+// Ends the test and updates the user's progress. Returns the command that
+// starts the personal best animation, if there is one to play.
+//
+// If the keyboard layout seems to have changed during the test, the result is
+// likely meaningless, so the user is asked whether to keep it first.
+func (m *Model) finish() tea.Cmd {
+	m.test.Finish()
+	m.view = STATS
+
+	if m.reference != "" {
+		m.aligned = align(m.reference, m.test.Prompt())
 	}
 
-	rand.Shuffle(len(words), func(i int, j int) {
-		words[i], words[j] = words[j], words[i]
-	})
+	// The demo isn't the user typing, so nothing about it is kept.
+	if m.demo != nil {
+		return demoNext()
+	}
+
+	if m.incomplete && m.settings.onQuit != SAVE_PARTIAL {
+		m.discarded = true
+		return nil
+	}
 
-	selection := words[:50]
-	prompt := strings.Join(selection, " ")
+	// The report of a playlist covers every test taken so far.
+	pl := m.settings.playlist
+	if pl != nil {
+		pl.reports = append(pl.reports, m.report())
+	}
+
+	if m.settings.report != "" {
+		var err error
+		if pl != nil {
+			err = saveReport(m.settings.report, pl.report())
+		} else {
+			err = saveReport(m.settings.report, m.report())
+		}
 
-	return Model{
-		prompt:     prompt,
-		userInput:  "",
-		cursor:     0,
-		mistakes:   0,
-		charsTyped: 0,
-		timePassed: 0,
-		timeLimit:  timeLimitDefault,
-		view:       PROMPT,
-		state:      READY,
+		if err != nil {
+			m.err = err
+			return nil
+		}
+	}
+
+	if m.keys.switched != "" {
+		m.confirm = true
+		return nil
 	}
+
+	return m.save()
 }
 
-// Get the words that will be used to construct the prompt.
-func getWords(name string) ([]string, error) {
-	file, err := os.Open(name)
+// Saves the result and updates the user's progress. Returns the command that
+// starts the personal best animation, if there is one to play.
+func (m *Model) save() tea.Cmd {
+	r := m.result()
+
+	results, err := m.store.Load()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %v", err)
+		m.err = err
+		return nil
 	}
-	defer file.Close()
 
-	data, err := io.ReadAll(file)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read file: %v", err)
+	best, ok := personalBest(results, r.key())
+	m.pb = ok && r.WPM > best.WPM && !r.Failed
+	m.previousPB = best.WPM
+
+	if err := m.store.Save(r); err != nil {
+		m.err = err
+		return nil
 	}
 
-	var words []string
-	if err := json.Unmarshal(data, &words); err != nil {
-		return nil, fmt.Errorf("failed to parse json: %v", err)
+	if m.settings.retention.enabled() {
+		if _, err := pruneHistory(m.store, m.dir, m.settings.retention, time.Now()); err != nil {
+			m.err = err
+			return nil
+		}
 	}
 
-	return words, nil
-}
+	// Without a prompt there is nothing to get wrong, so free typing says
+	// nothing about which characters the user struggles with.
+	if !m.test.Free() {
+		if err := m.review(); err != nil {
+			m.err = err
+			return nil
+		}
+	}
 
-// Runs once at the start of the application.
-func (m Model) Init() tea.Cmd {
-	return tick() // Starts the internal clock.
+	if !m.pb || m.settings.reducedMotion {
+		return nil
+	}
+
+	m.confetti = newConfetti()
+	return nextFrame()
 }
 
-// Ticks are used to represent time throughout the program.
-func tick() tea.Cmd {
-	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+// Update the user's per-character progress and weak spots with the test.
+func (m *Model) review() error {
+	p, err := loadProficiency(m.dir)
+	if err != nil {
+		return err
+	}
+
+	p.review(m.keys.stats, time.Now())
+	if err := p.save(m.dir); err != nil {
+		return err
+	}
+
+	w, err := loadWeakSpots(m.dir)
+	if err != nil {
+		return err
+	}
+
+	w.record(m.recorder)
+	return w.save(m.dir)
 }
 
-// Manages the state of the application.
-func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	if m.view == STATS {
-		return m, tea.Quit
+// Manages the stats screen. Tab or Enter starts another test right away (or
+// the next one, if more are lined up), and any other key quits, or goes back
+// to the user-switch screen when several users share the program.
+func (m Model) updateStats(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.confirm {
+		return m.updateConfirm(msg)
 	}
 
 	switch msg := msg.(type) {
+	case frameMsg:
+		m.frame++
+		if m.celebrating() {
+			return m, nextFrame()
+		}
+
 	case tickMsg:
-		if m.state == TYPING {
-			if m.timePassed >= m.timeLimit {
-				m.state = DONE
-				m.view = STATS
+		return m, tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter":
+			if m.hasNext() {
+				return m.startNext(), nil
 			}
 
-			m.timePassed++
+			return m.reroll(), nil
+
+		case "tab":
+			return m.reroll(), nil
+
+		case "r":
+			if len(m.recorder.presses) > 0 {
+				m.replay = m.newReplay(replaySpeedDefault)
+				m.view = REPLAY
+				return m, nextReplayFrame()
+			}
+		}
 
+		if m.settings.users {
+			return loginModel(m.settings, m.home), nil
 		}
 
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// Calculate the statistics for the test.
+func (m Model) result() Result {
+	score := m.test.Score()
+	if m.reference != "" {
+		score = m.transcriptionScore(score)
+	}
+
+	difficulty := m.settings.difficulty.String()
+	if m.level > 0 && m.settings.difficulty == NORMAL {
+		difficulty = "auto"
+	}
+
+	return Result{
+		Time:       m.test.Started().Add(score.Elapsed),
+		Mode:       m.mode.String(),
+		Duration:   int(m.test.Limit().Seconds()),
+		Language:   m.language,
+		Difficulty: difficulty,
+		Elapsed:    score.Elapsed.Seconds(),
+		WPM:        score.WPM,
+		Raw:        score.Raw,
+		Accuracy:   score.Accuracy,
+		Correct:    score.Correct,
+		Mistakes:   score.Mistakes,
+		Level:      m.level,
+		Layout:     m.settings.layout,
+		Keyboard:   m.settings.keyboard,
+		Incomplete: m.incomplete,
+		Failed:     m.failed(),
+		Shifted:    m.shiftedAccuracy(),
+		Scoring:    scoringVersion,
+		Settings: &TestSettings{
+			Mode:          m.mode.String(),
+			Duration:      int(m.test.Limit().Seconds()),
+			Language:      m.language,
+			Source:        m.settings.source.String(),
+			Words:         len(strings.Fields(m.test.Prompt())),
+			Tier:          m.prompt.tier,
+			Punctuation:   m.prompt.punctuation > 0,
+			Numbers:       m.prompt.numbers > 0,
+			Adaptive:      m.settings.adaptive,
+			Auto:          m.settings.auto,
+			Level:         m.level,
+			Theme:         m.settings.themeName,
+			Layout:        m.settings.layout,
+			Keyboard:      m.settings.keyboard,
+			Learn:         m.settings.learn,
+			Quote:         m.quoteAttribution(),
+			ReducedMotion: m.settings.reducedMotion,
+			ForgiveErased: m.settings.forgiveErased,
+			MinWPM:        m.settings.minWPM,
+		},
+	}
+}
+
+// Get who said the quote being typed, or nothing outside quote mode.
+func (m Model) quoteAttribution() string {
+	if m.quote == nil {
+		return ""
+	}
+
+	return m.quote.attribution()
+}
+
+// Asks the user whether to keep a result after a suspected layout switch.
+func (m Model) updateConfirm(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
 		return m, tick()
 
 	case tea.KeyMsg:
@@ -152,105 +1477,310 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "ctrl+c", "esc":
 			return m, tea.Quit
 
-		case "backspace":
-			if m.state == TYPING {
-				if m.cursor < 1 {
-					return m, nil
-				}
+		case "y":
+			m.confirm = false
+			return m, m.save()
 
-				m.cursor--
-				m.userInput = m.userInput[:m.cursor]
-			}
+		case "n":
+			m.confirm = false
+			m.discarded = true
+		}
+	}
 
-		default:
-			r := msg.Runes
+	return m, nil
+}
 
-			if len(r) < 1 {
-				return m, nil
-			}
+// Keeps track of the keystrokes of a test.
+type keyLog struct {
+	stats    map[string]*charStat // Keystrokes grouped by expected grapheme
+	recent   []keystroke          // Latest key presses, checked for layout switches
+	switched string               // Description of a suspected keyboard layout switch
+}
 
-			switch m.state {
-			case READY:
-				m.state = TYPING
-				fallthrough
-			case TYPING:
-				for i, c := range r {
-					if c != []rune(m.prompt)[m.cursor+i] {
-						m.mistakes++
-					}
-				}
+// Create an empty log of keystrokes.
+func newKeyLog() *keyLog {
+	return &keyLog{stats: make(map[string]*charStat)}
+}
 
-				m.userInput += string(r)
-				m.cursor += len(r)
-				m.charsTyped += len(r)
-			}
-		}
+// Keeps track of how often each character was typed correctly, and watches
+// for the keyboard layout changing mid-test.
+func (k *keyLog) observe(ev engine.Event) {
+	key, ok := ev.(engine.KeystrokeScored)
+	if !ok {
+		return
 	}
 
-	return m, nil
+	stat, ok := k.stats[key.Expected]
+	if !ok {
+		stat = &charStat{}
+		k.stats[key.Expected] = stat
+	}
+
+	stat.attempts++
+	if !key.Correct {
+		stat.misses++
+	}
+
+	k.recent = append(k.recent, keystroke{expected: key.Expected, typed: key.Typed})
+	if len(k.recent) > switchWindow {
+		k.recent = k.recent[1:]
+	}
+
+	if k.switched == "" {
+		k.switched, _ = detectLayoutSwitch(k.recent)
+	}
 }
 
 func (m Model) View() string {
-	timeRemaining := m.timeLimit - m.timePassed
 	s := ""
 
 	switch m.view {
 	case PROMPT:
-		s += fmt.Sprintf("%v\n\n", timeRemaining)
-		var readyToSplit = false
-		for i, c := range m.prompt {
-			if i >= terminalWidthDefault && i%terminalWidthDefault == 0 {
-				readyToSplit = true
-			}
+		var header []string
+		if m.user != "" {
+			header = append(header, m.user)
+		}
 
-			userInput := []rune(m.userInput)
+		if timer := m.timerView(); timer != "" {
+			header = append(header, timer)
+		}
 
-			if i < len(userInput) {
-				if userInput[i] == c {
-					s += string(c)
-				} else {
-					s += mistakeStyle.Render(string(c))
-				}
-			} else if i == m.cursor {
-				s += cursorStyle.Render(string(c))
-			} else {
-				s += promptStyle.Render(string(c))
-			}
+		if live := m.liveView(); live != "" {
+			header = append(header, live)
+		}
 
-			if readyToSplit && c == ' ' {
-				s += "\n"
-				readyToSplit = false
-			}
+		if m.settings.playlist != nil {
+			header = append(header, m.settings.playlist.view())
+		}
+
+		if m.settings.combo && !m.settings.noDistractions {
+			header = append(header, m.combo.view())
+		}
+
+		if m.reference != "" {
+			header = append(header, fmt.Sprintf("%v/%v words", m.wordsCommitted(), len(strings.Fields(m.reference))))
+		} else if m.test.Free() {
+			header = append(header, fmt.Sprintf("%v words", m.wordsCommitted()))
+		} else if m.test.Limit() == 0 {
+			header = append(header, fmt.Sprintf("%v/%v words", m.wordsCommitted(), len(strings.Fields(m.test.Prompt()))))
+		}
+
+		s += strings.Join(header, " | ") + "\n\n"
+
+		if m.settings.bigText && !m.test.Free() {
+			s += m.bigWordView() + "\n\n"
+		} else if m.mode == KIDS {
+			s += m.kidsBanner() + "\n\n"
+		}
+
+		if m.settings.learn != "" {
+			s += m.settings.theme.accent.Render(m.hintView()) + "\n\n"
+		}
+
+		if m.reference != "" {
+			s += m.referenceView()
+		}
+
+		s += m.direction.isolate(m.promptView())
+
+		if m.keys.switched != "" {
+			s += fmt.Sprintf("\n\nWarning: your keyboard layout seems to have changed (%v)", m.keys.switched)
 		}
 
-		s += "\n\nPress ESC to quit"
+		s += m.toastView()
+		if m.test.Paused() {
+			s += "\n\n" + m.settings.theme.accent.Render("Paused: switch back or keep typing to continue")
+		} else if time.Since(m.quitPressed) <= quitWindow {
+			s += "\n\n" + m.settings.theme.accent.Render("Press again to quit")
+		} else if m.reference != "" {
+			s += "\n\nCopy the text above, then press ESC to finish"
+		} else if m.test.Free() {
+			s += "\n\nType whatever comes to mind, then press ESC to finish"
+		} else if m.test.State() == engine.READY {
+			s += "\n\nStart typing when ready, Tab for a different prompt, or ESC to quit"
+		} else {
+			s += "\n\nTab to start over, ESC to quit"
+		}
+
+		if budget := m.budgetView(); budget != "" {
+			s += "\n" + budget
+		}
+	case REPLAY:
+		s += m.replayView()
+	case RESUME:
+		s += m.resumeView()
+	case LOGIN:
+		s += m.loginView()
+	case MENU:
+		s += m.menuView()
+		s += m.toastView()
+	case WORDS:
+		s += m.wordsErrorView()
+	case RECORDS:
+		s += m.recordsView()
+	case HISTORY:
+		s += m.historyView()
+	case WEAKSPOTS:
+		s += m.weakSpotsView()
 	case STATS:
 		s += "\n"
-		correct := m.charsTyped - m.mistakes
-		correctWords := float32(correct) / 5.0
-		wpm := correctWords * (60.0 / float32(m.timePassed))
-		s += fmt.Sprintf("WPM: %.2f\n", wpm)
+		if m.celebrating() {
+			s += m.confettiView() + "\n"
+		}
+
+		if m.pb {
+			s += m.settings.theme.accent.Render("New personal best!")
+			s += fmt.Sprintf(" +%.2f WPM over your previous best of %.2f\n\n", m.result().WPM-m.previousPB, m.previousPB)
+		}
+
+		if m.incomplete {
+			s += "Test ended early. Stats are for what you typed so far.\n\n"
+		}
 
-		correctWords = float32(m.charsTyped) / 5.0
-		raw := correctWords * (60.0 / float32(m.timePassed))
-		s += fmt.Sprintf("Raw: %.2f\n", raw)
+		if m.failed() {
+			s += m.failureView()
+		}
 
-		var accuracy float32
+		if m.repeats.dropped > 0 {
+			s += fmt.Sprintf("Ignored %v repeated keystrokes from a held key.\n\n", m.repeats.dropped)
+		}
 
-		if m.charsTyped < 1 {
-			accuracy = 0
+		if m.mode == KIDS {
+			s += m.kidsStatsView()
 		} else {
-			accuracy = (1.0 - (float32(m.mistakes) / float32(m.charsTyped))) * 100.0
+			s += m.statsView()
+			if graph := m.graphView(); graph != "" {
+				s += "\n" + graph + "\n"
+			}
+
+			if heatmap := m.heatmapView(); heatmap != "" {
+				s += "\n" + heatmap + "\n"
+			}
+
+			if keyboard := m.keyboardView(); keyboard != "" {
+				s += "\n" + keyboard + "\n"
+			}
+		}
+
+		if m.confirm {
+			s += fmt.Sprintf("\nYour keyboard layout seems to have changed during the test (%v).\n", m.keys.switched)
+			s += "Keep this result anyway? (y/n)\n"
+		} else if m.discarded {
+			s += "\nThis result was not saved.\n"
+		}
+
+		if m.err != nil {
+			s += fmt.Sprintf("\nFailed to save progress: %v\n", m.err)
+		}
+
+		other := "quit"
+		if m.settings.users {
+			other = "switch users"
+		}
+
+		if pl := m.settings.playlist; pl != nil && !m.hasNext() {
+			s += "\n" + pl.summaryView(m.settings.theme.accent)
+		}
+
+		if m.nextChunk() {
+			s += fmt.Sprintf("\nPress Enter for part %v of %v, Tab to try this one again, or any other key to %v\n", m.settings.chunk+2, len(m.settings.chunks), other)
+		} else if m.nextTest() {
+			s += fmt.Sprintf("\nPress Enter for test %v of %v, Tab to try this one again, or any other key to %v\n", m.settings.playlist.step+2, len(m.settings.playlist.Tests), other)
+		} else if !m.confirm {
+			s += fmt.Sprintf("\nPress Tab or Enter for another test, or any other key to %v\n", other)
+		}
+
+		if !m.confirm && len(m.recorder.presses) > 0 {
+			s += "Press R to watch a replay of the test\n"
 		}
+	}
+
+	s += "\n"
+	return s
+}
+
+// Count the words the user has finished by typing the space after them.
+func (m Model) wordsCommitted() int {
+	words := 0
+	cells := m.test.Cells()
+	for i, cell := range cells[:m.test.Cursor()] {
+		if cell.Space() && i > 0 && !cells[i-1].Space() {
+			words++
+		}
+	}
+
+	return words
+}
+
+// Render the text of the cell at index i in the style of its state.
+func (m Model) renderCell(i int, text string) string {
+	if m.settings.plain {
+		return m.plainCell(i, text)
+	}
+
+	theme := m.settings.theme
+	ready := m.test.State() == engine.READY && i != m.test.Cursor()
+	if m.test.Paused() || ready {
+		return theme.prompt.Faint(true).Render(text)
+	}
+
+	switch cell := m.test.Cells()[i]; {
+	case cell.Correct():
+		return theme.typed.Render(text)
+	case cell.State == engine.WRONG || cell.State == engine.SKIPPED:
+		return theme.mistake.Render(text)
+	case i == m.test.Cursor():
+		return m.caretView(text)
+	default:
+		return theme.prompt.Render(text)
+	}
+}
+
+// Render the calculated statistics.
+func (m Model) statsView() string {
+	r := m.result()
+	accent := m.settings.theme.accent
 
-		s += fmt.Sprintf("Accuracy: %.2f%%", accuracy)
+	s := accent.Render(bigNumber(r.WPM)) + "\n\n"
+	s += fmt.Sprintf("WPM: %v\n", accent.Render(fmt.Sprintf("%.2f", r.WPM)))
+	s += fmt.Sprintf("Raw: %v\n", accent.Render(fmt.Sprintf("%.2f", r.Raw)))
+
+	// Accuracy means nothing without a prompt, so the rhythm of the keys is
+	// shown instead.
+	if m.test.Free() && m.reference == "" {
+		s += m.recorder.rhythmView(accent)
+	} else {
+		s += fmt.Sprintf("Accuracy: %v", accent.Render(fmt.Sprintf("%.2f%%", r.Accuracy)))
 		s += fmt.Sprintf(
 			" (Correct: %v | Incorrect: %v)\n",
-			(m.charsTyped - m.mistakes),
-			m.mistakes,
+			r.Correct,
+			r.Mistakes,
 		)
 	}
 
-	s += "\n"
+	if m.reference != "" {
+		s += m.alignmentView()
+	}
+
+	if m.language == numberRowLanguage {
+		s += m.numberRowView(accent)
+	}
+
+	if m.test.Limit() == 0 {
+		s += fmt.Sprintf("Time: %v\n", accent.Render(fmt.Sprintf("%.1fs", r.Elapsed)))
+	}
+
+	if m.level > 0 {
+		s += fmt.Sprintf("Level: %v of %v\n", accent.Render(fmt.Sprint(m.level)), len(levels))
+	}
+
+	if m.quote != nil {
+		s += fmt.Sprintf("Quote: %v\n", m.quote.attribution())
+	}
+
+	if m.settings.combo {
+		s += fmt.Sprintf("Score: %v (Max combo: %v)\n", accent.Render(fmt.Sprint(m.combo.points)), accent.Render(fmt.Sprint(m.combo.best)))
+	}
+
 	return s
 }
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Name of the file that remembers the choices the user made in the menu.
+const preferencesFile = "preferences.json"
+
+// Longest custom duration, in seconds.
+const maxDuration = 3600
+
+// Most words a custom word count test can have.
+const maxWords = 1000
+
+// Durations the user can pick with a single key press, in seconds.
+var durationPresets = []int{15, 30, 60, 120}
+
+// Word counts the user can pick with a single key press.
+var wordPresets = []int{10, 25, 50, 100}
+
+// Represents the choices the user made last time, used as the new defaults.
+type preferences struct {
+	Duration int `json:"duration,omitempty"` // Time limit in seconds
+	Words    int `json:"words,omitempty"`    // Number of words in word count mode
+}
+
+// Represents the choices offered by the menu, which depend on the mode.
+type menuChoice struct {
+	title   string // Heading of the menu
+	presets []int  // Values the user can pick with a single key press
+	max     int    // Largest custom value
+	unit    string // Unit of the values, e.g. "seconds"
+}
+
+// Represents a row of the pre-test menu.
+type menuField int16
+
+const (
+	MODE_FIELD        menuField = iota // Kind of test
+	VALUE_FIELD                        // Duration or word count
+	LANGUAGE_FIELD                     // Word list
+	PUNCTUATION_FIELD                  // Whether words are followed by punctuation
+	NUMBERS_FIELD                      // Whether some words are replaced by numbers
+	SNIPPETS_FIELD                     // Programming language of the snippets in code mode
+	PRESET_FIELD                       // Named sets of settings from the config file
+)
+
+// Represents the pre-test menu.
+type menuState struct {
+	field    menuField  // Row the user is on
+	choice   menuChoice // What the menu asks for
+	selected int        // Index of the selected value, or len(presets) for custom
+	custom   string     // Digits typed for a custom value
+	preset   int        // Index of the selected test preset
+	err      string     // Problem with the custom value
+}
+
+// Get what the menu asks for in a mode.
+func menuChoices(mode Mode) menuChoice {
+	if mode == WORD_COUNT {
+		return menuChoice{title: "Words", presets: wordPresets, max: maxWords, unit: "words"}
+	}
+
+	return menuChoice{title: "Duration", presets: durationPresets, max: maxDuration, unit: "seconds"}
+}
+
+// Get the value the menu starts on in a mode.
+func menuValue(mode Mode, timeLimit int, words int) int {
+	if mode == WORD_COUNT {
+		return words
+	}
+
+	return timeLimit
+}
+
+// Get the choices saved in dir. Returns empty preferences if nothing has been
+// saved yet.
+func loadPreferences(dir string) (preferences, error) {
+	data, err := os.ReadFile(filepath.Join(dir, preferencesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return preferences{}, nil
+	} else if err != nil {
+		return preferences{}, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var p preferences
+	if err := json.Unmarshal(data, &p); err != nil {
+		return preferences{}, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return p, nil
+}
+
+// Write the user's choices to dir.
+func (p preferences) save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, preferencesFile), data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Builds the menu with the given value selected.
+func newMenu(choice menuChoice, value int) menuState {
+	for i, preset := range choice.presets {
+		if preset == value {
+			return menuState{field: VALUE_FIELD, choice: choice, selected: i}
+		}
+	}
+
+	return menuState{field: VALUE_FIELD, choice: choice, selected: len(choice.presets), custom: strconv.Itoa(value)}
+}
+
+// Get the rows of the menu that apply to the current settings, from the top.
+func (m Model) menuFields() []menuField {
+	fields := []menuField{MODE_FIELD}
+	if m.mode == TIME || m.mode == WORD_COUNT {
+		fields = append(fields, VALUE_FIELD)
+	}
+
+	// Only prompts made of random words come from a word list.
+	if m.settings.text == "" && m.settings.source == WORDLIST && m.mode != QUOTE && m.mode != ZEN && m.mode != CODE {
+		fields = append(fields, LANGUAGE_FIELD, PUNCTUATION_FIELD, NUMBERS_FIELD)
+	}
+
+	if m.settings.text == "" && m.mode == CODE {
+		fields = append(fields, SNIPPETS_FIELD)
+	}
+
+	if len(m.settings.presets) > 0 {
+		fields = append(fields, PRESET_FIELD)
+	}
+
+	return fields
+}
+
+// Get the word lists the menu offers, starting with the one in use if it
+// isn't installed on its own, e.g. a mix of several.
+func (m Model) menuLanguages() []string {
+	languages := installedLanguages()
+	if !slices.Contains(languages, m.settings.language) {
+		languages = append([]string{m.settings.language}, languages...)
+	}
+
+	return languages
+}
+
+// Manages the pre-test menu.
+func (m Model) updateMenu(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		custom := len(m.menu.choice.presets)
+		fields := m.menuFields()
+		i := max(slices.Index(fields, m.menu.field), 0)
+		m.menu.err = ""
+
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+
+		case "up", "shift+tab":
+			m.menu.field = fields[(i+len(fields)-1)%len(fields)]
+
+		case "down", "tab":
+			m.menu.field = fields[(i+1)%len(fields)]
+
+		case "left":
+			return m.changeMenu(-1), nil
+
+		case "right":
+			return m.changeMenu(1), nil
+
+		case "backspace":
+			if m.menu.field == VALUE_FIELD && m.menu.selected == custom && len(m.menu.custom) > 0 {
+				m.menu.custom = m.menu.custom[:len(m.menu.custom)-1]
+			}
+
+		case "enter":
+			return m.startFromMenu()
+
+		default:
+			if !slices.Contains(fields, VALUE_FIELD) {
+				return m, nil
+			}
+
+			for _, c := range msg.Runes {
+				if c < '0' || c > '9' {
+					continue
+				}
+
+				m.menu.field = VALUE_FIELD
+				if m.menu.selected != custom {
+					m.menu.selected = custom
+					m.menu.custom = ""
+				}
+
+				if len(m.menu.custom) < len(strconv.Itoa(m.menu.choice.max)) {
+					m.menu.custom += string(c)
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Move to the previous (-1) or next (1) choice on the current row. Changing
+// the mode, word list, punctuation, or numbers makes a new prompt to match.
+func (m Model) changeMenu(step int) Model {
+	settings := m.settings
+	cycle := func(i int, n int) int {
+		return (i + step + n) % n
+	}
+
+	switch m.menu.field {
+	case VALUE_FIELD:
+		m.menu.selected = cycle(m.menu.selected, len(m.menu.choice.presets)+1)
+		return m
+
+	case PRESET_FIELD:
+		m.menu.preset = cycle(m.menu.preset, len(m.settings.presets))
+		return m
+
+	case MODE_FIELD:
+		settings.mode = modes[cycle(slices.Index(modes, m.mode), len(modes))]
+
+	case LANGUAGE_FIELD:
+		languages := m.menuLanguages()
+		settings.language = languages[cycle(slices.Index(languages, settings.language), len(languages))]
+		settings.builtinWords = false
+
+	case PUNCTUATION_FIELD:
+		settings.punctuation = !settings.punctuation
+
+	case NUMBERS_FIELD:
+		settings.numbers = !settings.numbers
+
+	case SNIPPETS_FIELD:
+		languages := snippetLanguages()
+		settings.snippets = languages[cycle(slices.Index(languages, settings.snippets), len(languages))]
+	}
+
+	return m.rebuild(settings)
+}
+
+// Get a model for settings changed in the menu, with the menu still open.
+func (m Model) rebuild(settings Settings) Model {
+	next := newModel(settings, m.home, m.user)
+	if next.view == WORDS {
+		return next
+	}
+
+	if next.mode == m.mode {
+		next.menu = m.menu
+	} else {
+		next.menu.field = m.menu.field
+	}
+
+	next.dashboard = m.dashboard
+	next.ledger = m.ledger
+	next.view = MENU
+	return next
+}
+
+// Apply the chosen value, remember it for next time, and show the prompt.
+// Choosing a preset starts a new test with its settings instead.
+func (m Model) startFromMenu() (tea.Model, tea.Cmd) {
+	if m.menu.field == PRESET_FIELD {
+		name := presetNames(m.settings.presets)[m.menu.preset]
+		return newModel(m.settings.presets[name].apply(m.settings), m.home, m.user), nil
+	}
+
+	// Modes without a duration or word count are ready to go as they are.
+	if !slices.Contains(m.menuFields(), VALUE_FIELD) {
+		m.view = PROMPT
+		return m, nil
+	}
+
+	presets := m.menu.choice.presets
+
+	value := 0
+	if m.menu.selected < len(presets) {
+		value = presets[m.menu.selected]
+	} else {
+		value, _ = strconv.Atoi(m.menu.custom)
+	}
+
+	if value < 1 || value > m.menu.choice.max {
+		m.menu.err = fmt.Sprintf("please enter a number between 1 and %v %v", m.menu.choice.max, m.menu.choice.unit)
+		return m, nil
+	}
+
+	prefs, err := loadPreferences(m.dir)
+	if err != nil {
+		m.err = err
+	}
+
+	// The length of a word count test decides the prompt, so a new one is
+	// generated.
+	if m.mode == WORD_COUNT {
+		prefs.Words = value
+		if err := prefs.save(m.dir); err != nil {
+			m.err = err
+		}
+
+		next := newModel(m.settings, m.home, m.user)
+		if next.view == MENU {
+			next.view = PROMPT
+		}
+
+		return next, nil
+	}
+
+	prefs.Duration = value
+	m.test.SetLimit(time.Duration(value) * time.Second)
+	m.view = PROMPT
+
+	if err := prefs.save(m.dir); err != nil {
+		m.err = err
+	}
+
+	return m, nil
+}
+
+// Render a row of the menu, with the selected option highlighted. The row the
+// user is on is marked with an arrow.
+func (m Model) menuRow(field menuField, title string, options []string, selected int) string {
+	theme := m.settings.theme
+
+	s := "  "
+	if m.menu.field == field {
+		s = "> "
+	}
+
+	s += fmt.Sprintf("%-13v", title)
+	for i, option := range options {
+		label := fmt.Sprintf(" %v ", option)
+		if i == selected && m.settings.plain {
+			s += plainSelected(label)
+		} else if i == selected {
+			s += theme.cursor.Render(label)
+		} else {
+			s += theme.prompt.Render(label)
+		}
+
+		s += " "
+	}
+
+	return s + "\n\n"
+}
+
+// Render the pre-test menu.
+func (m Model) menuView() string {
+	s := m.dashboardView()
+
+	for _, field := range m.menuFields() {
+		switch field {
+		case MODE_FIELD:
+			names := make([]string, len(modes))
+			for i, mode := range modes {
+				names[i] = mode.String()
+			}
+
+			s += m.menuRow(field, "Mode", names, slices.Index(modes, m.mode))
+
+		case VALUE_FIELD:
+			var values []string
+			for _, preset := range m.menu.choice.presets {
+				values = append(values, strconv.Itoa(preset))
+			}
+
+			values = append(values, fmt.Sprintf("custom: %v", m.menu.custom))
+			s += m.menuRow(field, m.menu.choice.title, values, m.menu.selected)
+
+		case LANGUAGE_FIELD:
+			languages := m.menuLanguages()
+			names := make([]string, len(languages))
+			for i, language := range languages {
+				names[i] = languageName(language)
+			}
+
+			s += m.menuRow(field, "Word list", names, slices.Index(languages, m.settings.language))
+
+		case PUNCTUATION_FIELD:
+			selected := 0
+			if m.settings.punctuation {
+				selected = 1
+			}
+
+			s += m.menuRow(field, "Punctuation", []string{"off", "on"}, selected)
+
+		case NUMBERS_FIELD:
+			selected := 0
+			if m.settings.numbers {
+				selected = 1
+			}
+
+			s += m.menuRow(field, "Numbers", []string{"off", "on"}, selected)
+
+		case SNIPPETS_FIELD:
+			languages := snippetLanguages()
+			s += m.menuRow(field, "Snippets", languages, slices.Index(languages, m.settings.snippets))
+
+		case PRESET_FIELD:
+			// A preset is only picked once the user moves to it.
+			selected := -1
+			if m.menu.field == field {
+				selected = m.menu.preset
+			}
+
+			s += m.menuRow(field, "Presets", presetNames(m.settings.presets), selected)
+		}
+	}
+
+	if m.menu.err != "" {
+		s += m.settings.theme.mistake.Render(m.menu.err) + "\n\n"
+	}
+
+	s += "↑/↓ to move between rows, ←/→ to choose"
+	if slices.Contains(m.menuFields(), VALUE_FIELD) {
+		s += fmt.Sprintf(", type a number for a custom %v", strings.ToLower(m.menu.choice.title))
+	}
+
+	return s + ", Enter to start"
+}
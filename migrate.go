@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Name of the file that records which version of the history format is saved
+// next to it.
+const schemaFile = "schema.json"
+
+// Represents a change to the history format. It receives each saved result as
+// raw JSON fields, so it can rename, fill in, or remove fields that the
+// current Result type no longer knows about.
+type migration func(record map[string]any)
+
+// Every change to the history format, oldest first. The history is at version
+// n after the first n migrations have been applied, so new migrations must
+// only ever be added to the end.
+var migrations = []migration{
+	fillDefaults,
+}
+
+// Version of the history format written by this build.
+var schemaVersion = len(migrations)
+
+// Represents the contents of the schema file.
+type schema struct {
+	Version int `json:"version"`
+}
+
+// Fill in the fields that results saved before tests had modes, languages, and
+// difficulties are missing.
+func fillDefaults(record map[string]any) {
+	defaults := map[string]any{
+		"mode":       "time",
+		"language":   languageDefault,
+		"difficulty": "normal",
+	}
+
+	for field, value := range defaults {
+		if v, ok := record[field]; !ok || v == "" {
+			record[field] = value
+		}
+	}
+}
+
+// Get the version of the history saved in dir. History saved before the
+// schema file was introduced is version 0.
+func loadSchemaVersion(dir string) (int, error) {
+	data, err := os.ReadFile(filepath.Join(dir, schemaFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var s schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return 0, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return s.Version, nil
+}
+
+// Record that the history saved in dir is at the current version.
+func saveSchemaVersion(dir string) error {
+	data, err := json.MarshalIndent(schema{Version: schemaVersion}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, schemaFile), data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Bring the history saved in dir up to the current version. A copy of the
+// history is kept as history.jsonl.bak before anything is changed.
+func migrateHistory(dir string) error {
+	name := filepath.Join(dir, historyFile)
+	if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open file: %v", err)
+	}
+
+	version, err := loadSchemaVersion(dir)
+	if err != nil {
+		return err
+	}
+
+	if version > schemaVersion {
+		return fmt.Errorf("history is at version %v, but this build only understands up to version %v", version, schemaVersion)
+	}
+
+	if version == schemaVersion {
+		return nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %v", err)
+	}
+
+	if err := writeFile(name+".bak", data, 0o644); err != nil {
+		return fmt.Errorf("failed to back up history: %v", err)
+	}
+
+	records, err := loadRecords(name)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		for _, migrate := range migrations[version:] {
+			migrate(record)
+		}
+	}
+
+	if err := writeLines(name, records); err != nil {
+		return err
+	}
+
+	return saveSchemaVersion(dir)
+}
+
+// Get every result in a history file as raw JSON fields.
+func loadRecords(name string) ([]map[string]any, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	var records []map[string]any
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	for {
+		var record map[string]any
+		if err := decoder.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse json: %v", err)
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
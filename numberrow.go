@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Keys of the number row, and the symbols typed on them with shift, on a US
+// keyboard.
+const (
+	numberRowDigits  = "1234567890"
+	numberRowSymbols = "!@#$%^&*()"
+)
+
+// Name the number row drill is saved under in place of a word list.
+const numberRowLanguage = "number row"
+
+// Shortest and longest run of neighboring keys in a group of the drill.
+const (
+	minNumberRun = 2
+	maxNumberRun = 4
+)
+
+// Build a number row drill of n groups. Each group is a short run of
+// neighboring keys, typed in one of a few patterns that mix the digits with
+// their shifted symbols, e.g. "3#3#", "234@#$", "2@3#4$", or "$4#3@2".
+func numberRowPrompt(n int, rng *rand.Rand) string {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	groups := make([]string, n)
+	for i := range groups {
+		length := minNumberRun + rng.Intn(maxNumberRun-minNumberRun+1)
+		start := rng.Intn(len(numberRowDigits) - length + 1)
+		digits := numberRowDigits[start : start+length]
+		symbols := numberRowSymbols[start : start+length]
+
+		var group string
+		switch rng.Intn(4) {
+		case 0:
+			group = strings.Repeat(digits[:1]+symbols[:1], 2)
+		case 1:
+			group = digits + symbols
+		case 2:
+			for j := range length {
+				group += digits[j:j+1] + symbols[j:j+1]
+			}
+		default:
+			for j := length - 1; j >= 0; j-- {
+				group += symbols[j:j+1] + digits[j:j+1]
+			}
+		}
+
+		groups[i] = group
+	}
+
+	return strings.Join(groups, " ")
+}
+
+// Get the accuracy of the test's keystrokes on the given characters, and
+// whether any of them were typed at all.
+func (k *keyLog) accuracy(chars string) (float64, bool) {
+	attempts, misses := 0, 0
+	for _, c := range chars {
+		if stat, ok := k.stats[string(c)]; ok {
+			attempts += stat.attempts
+			misses += stat.misses
+		}
+	}
+
+	if attempts == 0 {
+		return 0, false
+	}
+
+	return (1 - float64(misses)/float64(attempts)) * 100, true
+}
+
+// Get the accuracy of the shifted symbols in a number row drill, or 0 for any
+// other test.
+func (m Model) shiftedAccuracy() float64 {
+	if m.language != numberRowLanguage {
+		return 0
+	}
+
+	shifted, _ := m.keys.accuracy(numberRowSymbols)
+	return shifted
+}
+
+// Render the accuracy of the digits and of their shifted symbols apart, since
+// the shifted ones are usually much worse.
+func (m Model) numberRowView(accent lipgloss.Style) string {
+	s := ""
+	if digits, ok := m.keys.accuracy(numberRowDigits); ok {
+		s += fmt.Sprintf("Digits: %v", accent.Render(fmt.Sprintf("%.2f%%", digits)))
+	}
+
+	if shifted, ok := m.keys.accuracy(numberRowSymbols); ok {
+		if s != "" {
+			s += " | "
+		}
+
+		s += fmt.Sprintf("Shifted: %v", accent.Render(fmt.Sprintf("%.2f%%", shifted)))
+	}
+
+	if s == "" {
+		return ""
+	}
+
+	return s + "\n"
+}
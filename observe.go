@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Represents a single step of the application: the message that was handled,
+// and the model before and after handling it. The typing test is shared
+// between the two models, so changes to it are best followed through the
+// engine's events.
+type Transition struct {
+	Msg    tea.Msg
+	Before Model
+	After  Model
+}
+
+// Represents a function called after every message is handled, e.g. to log
+// what happened or capture a replay. Observers only watch; they can't change
+// the model.
+type Observer func(t Transition)
+
+// Wraps a model so every observer is called after each update, keeping
+// cross-cutting concerns out of Update itself.
+type observedModel struct {
+	model     Model
+	observers []Observer
+}
+
+// Wrap a model with the given observers. Without any, the model is returned
+// unchanged.
+func observe(m Model, observers ...Observer) tea.Model {
+	if len(observers) == 0 {
+		return m
+	}
+
+	return observedModel{model: m, observers: observers}
+}
+
+// Get the model inside a model that may have been wrapped with observers.
+func unwrap(m tea.Model) Model {
+	if o, ok := m.(observedModel); ok {
+		return o.model
+	}
+
+	return m.(Model)
+}
+
+func (o observedModel) Init() tea.Cmd {
+	return o.model.Init()
+}
+
+func (o observedModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	next, cmd := o.model.Update(msg)
+	after := next.(Model)
+
+	for _, fn := range o.observers {
+		fn(Transition{Msg: msg, Before: o.model, After: after})
+	}
+
+	o.model = after
+	return o, cmd
+}
+
+func (o observedModel) View() string {
+	return o.model.View()
+}
+
+// Write every message, and any change of screen, to the log.
+func logTransitions(t Transition) {
+	if t.Before.view != t.After.view {
+		log.Printf("%T: view %v -> %v", t.Msg, t.Before.view, t.After.view)
+	} else {
+		log.Printf("%T", t.Msg)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Appended to a client's key to accept a WebSocket connection (RFC 6455).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Number of updates queued for a client before new ones are dropped, so a
+// slow overlay can't hold up the test.
+const overlayQueue = 16
+
+// Represents the live state of the test, as sent to overlays.
+type OverlayState struct {
+	State    string  `json:"state"`    // "idle", "ready", "typing", or "done"
+	Mode     string  `json:"mode"`     // Kind of test, e.g. "time"
+	Language string  `json:"language"` // Name of the word list
+	WPM      float64 `json:"wpm"`      // Words per minute so far, excluding mistakes
+	Raw      float64 `json:"raw"`      // Words per minute so far, including mistakes
+	Accuracy float64 `json:"accuracy"` // Percentage of correct keystrokes
+	Mistakes int     `json:"mistakes"` // Number of typos
+	Elapsed  float64 `json:"elapsed"`  // Seconds spent typing
+	Limit    float64 `json:"limit"`    // Time limit in seconds (0 for none)
+	Progress float64 `json:"progress"` // How much of the test is done, from 0 to 1
+}
+
+// Serves the live state of the test over WebSocket, for overlays like OBS
+// browser sources. Plain HTTP requests get the current state once.
+type overlayServer struct {
+	mu      sync.Mutex
+	clients map[chan []byte]bool
+	last    []byte   // Most recent state, sent to clients as they connect
+	origins []string // Web pages allowed to connect besides the server's own
+}
+
+// Start serving overlays on addr. A bare port is served on localhost only.
+func startOverlay(addr string, origins []string) (*overlayServer, error) {
+	if _, err := strconv.Atoi(addr); err == nil {
+		addr = "localhost:" + addr
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start overlay server: %v", err)
+	}
+
+	s := &overlayServer{clients: make(map[chan []byte]bool), origins: origins}
+	s.last, _ = json.Marshal(OverlayState{State: "idle"})
+	go http.Serve(listener, s)
+	return s, nil
+}
+
+// Get the live state of the test from the model.
+func overlayState(m Model) OverlayState {
+	if m.test == nil || (m.view != PROMPT && m.view != STATS) {
+		return OverlayState{State: "idle"}
+	}
+
+	score := m.test.Score()
+	s := OverlayState{
+		Mode:     m.mode.String(),
+		Language: m.language,
+		WPM:      score.WPM,
+		Raw:      score.Raw,
+		Accuracy: score.Accuracy,
+		Mistakes: score.Mistakes,
+		Elapsed:  score.Elapsed.Seconds(),
+		Limit:    m.test.Limit().Seconds(),
+	}
+
+	switch {
+	case m.view == STATS:
+		s.State = "done"
+		s.Progress = 1
+	case m.test.State() == engine.TYPING:
+		s.State = "typing"
+	default:
+		s.State = "ready"
+	}
+
+	if s.State == "typing" {
+		if s.Limit > 0 {
+			s.Progress = min(s.Elapsed/s.Limit, 1)
+		} else if n := len(m.test.Cells()); n > 0 {
+			s.Progress = float64(m.test.Cursor()) / float64(n)
+		}
+	}
+
+	return s
+}
+
+// Send the state of the test to every overlay whenever it changes.
+func (s *overlayServer) observe(t Transition) {
+	data, err := json.Marshal(overlayState(t.After))
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if string(data) == string(s.last) {
+		return
+	}
+
+	s.last = data
+	for client := range s.clients {
+		select {
+		case client <- data:
+		default:
+		}
+	}
+}
+
+// Report whether the page a request came from may read the state. Requests
+// from outside a browser have no origin, and a page served from the same
+// address as the overlay is always allowed.
+func (s *overlayServer) allowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	if u, err := url.Parse(origin); err == nil && u.Host == r.Host {
+		return true
+	}
+
+	return slices.ContainsFunc(s.origins, func(o string) bool {
+		return o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin)
+	})
+}
+
+func (s *overlayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		s.mu.Lock()
+		data := s.last
+		s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Vary", "Origin")
+		if origin := r.Header.Get("Origin"); origin != "" && s.allowed(r) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Write(data)
+		return
+	}
+
+	// Any web page can open a WebSocket, so only the ones the user trusts may.
+	if !s.allowed(r) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+		return
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	hijacker, ok := w.(http.Hijacker)
+	if key == "" || !ok {
+		http.Error(w, "invalid websocket request", http.StatusBadRequest)
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n")
+	fmt.Fprintf(rw, "Upgrade: websocket\r\nConnection: Upgrade\r\n")
+	fmt.Fprintf(rw, "Sec-WebSocket-Accept: %v\r\n\r\n", base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	client := make(chan []byte, overlayQueue)
+	s.mu.Lock()
+	s.clients[client] = true
+	client <- s.last
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, client)
+		s.mu.Unlock()
+	}()
+
+	// Frames from the overlay are only read to answer pings and notice when
+	// it goes away.
+	closed := make(chan struct{})
+	control := make(chan []byte, 1)
+	go func() {
+		defer close(closed)
+		readFrames(rw.Reader, control)
+	}()
+
+	for {
+		select {
+		case data := <-client:
+			if writeFrame(conn, 0x1, data) != nil {
+				return
+			}
+		case frame := <-control:
+			if writeFrame(conn, frame[0], frame[1:]) != nil {
+				return
+			}
+		case <-closed:
+			writeFrame(conn, 0x8, nil)
+			return
+		}
+	}
+}
+
+// Write a single unmasked frame, as servers do, with the given opcode.
+func writeFrame(w io.Writer, opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xffff:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_, err := w.Write(append(header, payload...))
+	return err
+}
+
+// Read frames from an overlay until it closes the connection, queueing a
+// pong for every ping.
+func readFrames(r *bufio.Reader, control chan<- []byte) error {
+	for {
+		var header [2]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+
+		opcode := header[0] & 0x0f
+		masked := header[1]&0x80 != 0
+		n := uint64(header[1] & 0x7f)
+
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+
+		var mask [4]byte
+		if masked {
+			if _, err := io.ReadFull(r, mask[:]); err != nil {
+				return err
+			}
+		}
+
+		// Overlays only listen, so there's nothing worth reading at length.
+		if n > 1<<16 {
+			return errors.New("frame too large")
+		}
+
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case 0x8:
+			return nil
+		case 0x9:
+			select {
+			case control <- append([]byte{0xa}, payload...):
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Name of the program's folder in each of the user's directories.
+const appName = "typing-tui"
+
+// Environment variables that override where the program keeps its files. The
+// flags of the same names (e.g. --data-dir) set them.
+const (
+	configDirEnv = "TYPING_TUI_CONFIG_DIR"
+	dataDirEnv   = "TYPING_TUI_DATA_DIR"
+	cacheDirEnv  = "TYPING_TUI_CACHE_DIR"
+	wordsDirEnv  = "TYPING_TUI_WORDS_DIR"
+)
+
+// Flags that choose a directory, and the environment variable each one sets.
+// They are read before the config file, so they can't be in it.
+var dirFlags = []struct {
+	name  string
+	env   string
+	usage string
+}{
+	{"config-dir", configDirEnv, "directory to read the config file from"},
+	{"data-dir", dataDirEnv, "directory to save history, progress, and users in"},
+	{"cache-dir", cacheDirEnv, "directory to cache text fetched online in"},
+	{"words-dir", wordsDirEnv, "directory to read word lists from"},
+}
+
+// Apply the directory flags in args, ahead of the other flags, since the
+// config file they may point to decides the defaults of the rest.
+func applyDirFlags(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimLeft(args[i], "-")
+		if arg == args[i] {
+			continue
+		}
+
+		name, value, ok := strings.Cut(arg, "=")
+		for _, f := range dirFlags {
+			if name != f.name {
+				continue
+			}
+
+			if !ok {
+				if i+1 >= len(args) {
+					return fmt.Errorf("flag needs an argument: --%v", f.name)
+				}
+
+				i++
+				value = args[i]
+			}
+
+			if err := os.Setenv(f.env, value); err != nil {
+				return fmt.Errorf("failed to set %v: %v", f.env, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Get the directory where the config file is stored.
+func configDir() (string, error) {
+	if dir := os.Getenv(configDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config directory: %v", err)
+	}
+
+	return filepath.Join(dir, appName), nil
+}
+
+// Get the directory where the program stores its data: the data directory
+// of each operating system (e.g. ~/.local/share on Linux). Earlier versions
+// kept data next to the config file, so that's used instead if it already
+// has data in it and the new directory doesn't exist yet.
+func dataDir() (string, error) {
+	if dir := os.Getenv(dataDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	base, err := userDataDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(base, appName)
+	if _, err := os.Stat(dir); err == nil {
+		return dir, nil
+	}
+
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return dir, nil
+	}
+
+	legacy := filepath.Join(config, appName)
+	entries, err := os.ReadDir(legacy)
+	if err != nil {
+		return dir, nil
+	}
+
+	for _, entry := range entries {
+		if entry.Name() != configFile {
+			return legacy, nil
+		}
+	}
+
+	return dir, nil
+}
+
+// Get the directory each operating system keeps application data in.
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+
+		return "", errors.New("failed to find data directory: %LocalAppData% is not defined")
+	case "darwin", "ios", "plan9":
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find data directory: %v", err)
+		}
+
+		return dir, nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return dir, nil
+		}
+
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find data directory: %v", err)
+		}
+
+		return filepath.Join(home, ".local", "share"), nil
+	}
+}
+
+// Get the directory where text fetched online is cached.
+func cacheDir() (string, error) {
+	if dir := os.Getenv(cacheDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %v", err)
+	}
+
+	return filepath.Join(dir, appName), nil
+}
+
+// Find the directory the word lists are read from: the one the user chose
+// with --words-dir or its environment variable, or else the words folder in
+// the data directory. The working directory is never looked in, so a folder
+// that happens to be called "words" can't replace the built-in lists.
+func findWordsDir() (string, error) {
+	if dir := os.Getenv(wordsDirEnv); dir != "" {
+		return dir, nil
+	}
+
+	home, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(home, "words"), nil
+}
+
+// Prints where the program keeps its files.
+func paths(cfg Config) int {
+	config, err := configDir()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	data, err := dataDir()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	cache, err := cacheDir()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	words, err := filepath.Abs(wordsDir)
+	if err != nil {
+		words = wordsDir
+	}
+
+	fmt.Printf("config:  %v\n", filepath.Join(config, configFile))
+	fmt.Printf("data:    %v\n", data)
+	fmt.Printf("history: %v\n", filepath.Join(data, cfg.Storage.file()))
+	fmt.Printf("cache:   %v\n", cache)
+	fmt.Printf("words:   %v\n", words)
+	return 0
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// How badly a word has to be typed to be marked on the heat map in plain
+// mode, where it has no colors.
+const plainBadness = 0.5
+
+// Report whether the terminal can't show colors or text styles at all.
+func dumbTerminal() bool {
+	return os.Getenv("TERM") == "dumb"
+}
+
+// Draw everything without colors or text styles, for plain mode. Mistakes and
+// the cursor are marked with text instead.
+func usePlain() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+}
+
+// Render a cell of the prompt in plain mode: mistakes are wrapped in brackets,
+// and the cursor is a bar before the character the user is on.
+func (m Model) plainCell(i int, text string) string {
+	switch cell := m.test.Cells()[i]; {
+	case cell.State == engine.WRONG || cell.State == engine.SKIPPED:
+		return "[" + text + "]"
+	case i == m.test.Cursor() && m.test.State() != engine.DONE:
+		return "|" + text
+	default:
+		return text
+	}
+}
+
+// Render the block shown where text is entered. In plain mode, it's an
+// underscore, since a colored space can't be seen.
+func (m Model) cursorBlock() string {
+	if m.settings.plain {
+		return "_"
+	}
+
+	return m.caretView(" ")
+}
+
+// Render the selected option of a menu row in plain mode.
+func plainSelected(label string) string {
+	return "[" + label[1:len(label)-1] + "]"
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Represents a file listing tests to take one after another, e.g.
+//
+//	name = "Monday routine"
+//
+//	[[tests]]
+//	mode = "time"
+//	duration = 60
+//
+//	[[tests]]
+//	mode = "words"
+//	words = 50
+//	punctuation = true
+//
+// Each test takes the same settings as a preset.
+type Playlist struct {
+	Name  string   `toml:"name"`  // Name shown while the tests are taken
+	Tests []Preset `toml:"tests"` // Tests to take, in order
+}
+
+// Keeps track of a playlist while its tests are taken.
+type playlist struct {
+	Playlist
+	file    string   // File the playlist was read from
+	base    Settings // Settings the program was started with, before any test's
+	step    int      // Index of the test being taken
+	reports []Report // Reports of the tests finished so far
+}
+
+// Represents the results of every test in a playlist, as written to the
+// report file.
+type PlaylistReport struct {
+	Version int      `json:"version"` // Version of the report format
+	Name    string   `json:"name"`    // Name of the playlist
+	Tests   []Report `json:"tests"`   // Every test finished so far, in order
+}
+
+// Read a playlist file and make sure every test in it can be taken.
+func loadPlaylist(name string) (Playlist, error) {
+	var p Playlist
+	if _, err := toml.DecodeFile(name, &p); err != nil {
+		return Playlist{}, fmt.Errorf("failed to parse playlist: %v", err)
+	}
+
+	if len(p.Tests) == 0 {
+		return Playlist{}, errors.New("playlist has no tests")
+	}
+
+	for i, test := range p.Tests {
+		if err := test.validate(fmt.Sprintf("test %v of the playlist", i+1)); err != nil {
+			return Playlist{}, err
+		}
+	}
+
+	return p, nil
+}
+
+// Start a playlist: get the settings of its first test.
+func startPlaylist(p Playlist, file string, settings Settings) Settings {
+	pl := &playlist{Playlist: p, file: file, base: settings}
+	return pl.settings()
+}
+
+// Get the settings of the test being taken.
+func (pl *playlist) settings() Settings {
+	settings := pl.Tests[pl.step].apply(pl.base)
+	settings.playlist = pl
+	return settings
+}
+
+// Report whether there's a test left to take after the current one.
+func (m Model) nextTest() bool {
+	pl := m.settings.playlist
+	return pl != nil && pl.step+1 < len(pl.Tests)
+}
+
+// Get a model for the next test of the playlist.
+func (m Model) startNextTest() Model {
+	pl := m.settings.playlist
+	pl.step++
+	return newModel(pl.settings(), m.home, m.user)
+}
+
+// Get the report of every test finished so far.
+func (pl *playlist) report() PlaylistReport {
+	return PlaylistReport{
+		Version: reportVersion,
+		Name:    pl.Name,
+		Tests:   pl.reports,
+	}
+}
+
+// Render the playlist's progress for the header while typing.
+func (pl *playlist) view() string {
+	name := pl.Name
+	if name == "" {
+		name = "playlist"
+	}
+
+	return fmt.Sprintf("%v %v/%v", name, pl.step+1, len(pl.Tests))
+}
+
+// Render the average speed and accuracy of every test in the playlist, once
+// the last one is done.
+func (pl *playlist) summaryView(accent lipgloss.Style) string {
+	if len(pl.reports) == 0 {
+		return ""
+	}
+
+	var wpm, accuracy float64
+	var elapsed time.Duration
+	for _, r := range pl.reports {
+		wpm += r.Summary.WPM
+		accuracy += r.Summary.Accuracy
+		elapsed += time.Duration(r.Summary.Elapsed * float64(time.Second))
+	}
+
+	n := float64(len(pl.reports))
+	return fmt.Sprintf(
+		"Playlist done: %v tests, %v WPM and %v accuracy on average, %v in total\n",
+		len(pl.reports),
+		accent.Render(fmt.Sprintf("%.2f", wpm/n)),
+		accent.Render(fmt.Sprintf("%.2f%%", accuracy/n)),
+		elapsed.Round(time.Second),
+	)
+}
+
+// Report whether another test follows this one, either the next part of the
+// text or the next test of the playlist.
+func (m Model) hasNext() bool {
+	return m.nextChunk() || m.nextTest()
+}
+
+// Get a model for the test that follows this one. The text is typed in full
+// before moving on to the next test of the playlist.
+func (m Model) startNext() Model {
+	if m.nextChunk() {
+		return m.startNextChunk()
+	}
+
+	return m.startNextTest()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Chance that a word is followed by punctuation when punctuation is on.
+const punctuationDefault = 0.2
+
+// Chance that a word is replaced by a number when numbers are on.
+const numbersDefault = 0.1
+
+// Represents a named set of test settings from the config file, e.g.
+//
+//	[presets.work-warmup]
+//	duration = 60
+//	languages = ["english-1k"]
+//	punctuation = true
+type Preset struct {
+	Mode        Mode       `toml:"mode"`                  // Kind of test to take
+	Duration    int        `toml:"duration,omitzero"`     // Time limit in seconds (0 to ask in the menu)
+	Words       int        `toml:"words,omitzero"`        // Number of words in word count mode (0 to ask in the menu)
+	Tier        int        `toml:"tier,omitzero"`         // Only pick from this many of the most common words (0 for all)
+	Languages   []string   `toml:"languages,omitempty"`   // Word lists to mix into the prompt
+	Source      Source     `toml:"source,omitzero"`       // Where the words of the prompt come from
+	Punctuation bool       `toml:"punctuation,omitempty"` // Follow some words with punctuation
+	Numbers     bool       `toml:"numbers,omitempty"`     // Replace some words with numbers
+	Adaptive    bool       `toml:"adaptive,omitempty"`    // Practice weak characters more often
+	Auto        bool       `toml:"auto,omitempty"`        // Adjust the difficulty based on recent results
+	Difficulty  Difficulty `toml:"difficulty,omitzero"`   // How mistakes are dealt with
+}
+
+// Make sure the preset describes a test that can be taken.
+func (p Preset) validate(name string) error {
+	if p.Duration < 0 || p.Duration > maxDuration {
+		return fmt.Errorf("invalid duration for preset %v: %v (expected 0 to %v seconds)", name, p.Duration, maxDuration)
+	}
+
+	if p.Words < 0 || p.Words > maxWords {
+		return fmt.Errorf("invalid word count for preset %v: %v (expected 0 to %v words)", name, p.Words, maxWords)
+	}
+
+	if p.Tier < 0 {
+		return fmt.Errorf("invalid tier for preset %v: %v (expected 0 or more words)", name, p.Tier)
+	}
+
+	return nil
+}
+
+// Get the settings with the preset's choices applied.
+func (p Preset) apply(settings Settings) Settings {
+	settings.mode = p.Mode
+	settings.duration = p.Duration
+	settings.words = p.Words
+	settings.tier = p.Tier
+	settings.source = p.Source
+	settings.punctuation = p.Punctuation
+	settings.numbers = p.Numbers
+	settings.adaptive = p.Adaptive
+	settings.auto = p.Auto
+	settings.difficulty = p.Difficulty
+
+	if len(p.Languages) > 0 {
+		settings.language = strings.Join(p.Languages, languageSeparator)
+		settings.builtinWords = false
+	}
+
+	return settings
+}
+
+// Get the names of every preset, in the order they are listed to the user.
+func presetNames(presets map[string]Preset) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Get a preset by name.
+func lookupPreset(presets map[string]Preset, name string) (Preset, error) {
+	p, ok := presets[name]
+	if !ok {
+		return Preset{}, fmt.Errorf("unknown preset: %v (expected one of: %v)", name, strings.Join(presetNames(presets), ", "))
+	}
+
+	return p, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the file that stores the user's per-character progress.
+const proficiencyFile = "proficiency.json"
+
+// A character needs to be typed at least this many times in a test before
+// the result is used to reschedule it.
+const minAttempts = 3
+
+// Accuracy required for a character to move up to the next box.
+const passAccuracy = 0.95
+
+// How long to wait before practicing a character again, indexed by box.
+var intervals = []time.Duration{
+	0,
+	10 * time.Minute,
+	time.Hour,
+	24 * time.Hour,
+	3 * 24 * time.Hour,
+	7 * 24 * time.Hour,
+	14 * 24 * time.Hour,
+}
+
+// Counts the keystrokes made for a single expected character during a test.
+type charStat struct {
+	attempts int // Times the character was expected
+	misses   int // Times something else was typed instead
+}
+
+// Represents how well the user knows a single character.
+//
+// Characters are sorted into boxes (Leitner system): typing a character
+// accurately moves it up a box and pushes its next review further out, while
+// struggling with it sends it back to the first box to be practiced right away.
+type card struct {
+	Box      int       `json:"box"`      // Higher means better known
+	Due      time.Time `json:"due"`      // When the character should be practiced next
+	Attempts int       `json:"attempts"` // Times the character was expected overall
+	Misses   int       `json:"misses"`   // Times the character was mistyped overall
+}
+
+// Maps each character the user has practiced to its schedule.
+type proficiency map[string]*card
+
+// Get the progress saved in dir. Returns an empty set if nothing has been
+// saved yet.
+func loadProficiency(dir string) (proficiency, error) {
+	data, err := os.ReadFile(filepath.Join(dir, proficiencyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return make(proficiency), nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	p := make(proficiency)
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return p, nil
+}
+
+// Write the user's progress to dir.
+func (p proficiency) save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, proficiencyFile), data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reschedule every character that was typed often enough during a test.
+// Spaces, tabs, and line breaks are left out, since they're never worth
+// drilling.
+func (p proficiency) review(stats map[string]*charStat, now time.Time) {
+	for g, stat := range stats {
+		if strings.TrimSpace(g) == "" || stat.attempts < minAttempts {
+			continue
+		}
+
+		c, ok := p[g]
+		if !ok {
+			c = &card{}
+			p[g] = c
+		}
+
+		c.Attempts += stat.attempts
+		c.Misses += stat.misses
+
+		accuracy := 1.0 - float64(stat.misses)/float64(stat.attempts)
+		if accuracy >= passAccuracy {
+			c.Box = min(c.Box+1, len(intervals)-1)
+		} else {
+			c.Box = 0
+		}
+
+		c.Due = now.Add(intervals[c.Box])
+	}
+}
+
+// Get up to n characters that are due for practice, weakest first.
+func (p proficiency) due(now time.Time, n int) []string {
+	var chars []string
+	for s, c := range p {
+		// Earlier versions scheduled line breaks and tabs too.
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+
+		if !c.Due.After(now) {
+			chars = append(chars, s)
+		}
+	}
+
+	sort.Slice(chars, func(i int, j int) bool {
+		a, b := p[chars[i]], p[chars[j]]
+		if a.Box != b.Box {
+			return a.Box < b.Box
+		}
+
+		return a.Due.Before(b.Due)
+	})
+
+	return chars[:min(n, len(chars))]
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Describes how a prompt should be generated.
+type promptConfig struct {
+	words       int        // Number of words in the prompt
+	tier        int        // Only pick from this many of the most common words (0 for all)
+	focus       []string   // Characters to practice more often
+	punctuation float64    // Chance that a word is followed by punctuation
+	numbers     float64    // Chance that a word is replaced by a number
+	drill       []string   // Only pick words that contain one of these (empty for any word)
+	rng         *rand.Rand // Source of randomness, so a prompt can be made again from a seed (nil for a random one)
+}
+
+// Added to each word's rank when weighting a pool by frequency, so the few most
+// common words don't crowd out the rest.
+const rankOffset = 10
+
+// Share of punctuated words that are wrapped in quotes as well.
+const quoteShare = 0.25
+
+// Most digits in a number put into the prompt.
+const maxDigits = 4
+
+// Marks that can follow a word, along with how often each one is picked.
+var punctuationMarks = []struct {
+	mark   string
+	weight int
+}{
+	{",", 6},
+	{".", 6},
+	{"?", 1},
+	{"!", 1},
+	{";", 1},
+}
+
+// Builds a prompt of randomly picked words.
+//
+// When focus characters are given, every other word is picked from the words
+// that contain at least one of them, so characters the user struggles with
+// show up more often.
+//
+// When drill strings are given, only words containing one of them are picked.
+// If the word list has none, the drill strings themselves are typed instead.
+//
+// When the words are limited to a tier of the most common ones, they're picked
+// by frequency rather than evenly, so the most common words of a short pool
+// come up most often, as they do in real text.
+func generatePrompt(words []string, cfg promptConfig) string {
+	rng := cfg.rng
+	if rng == nil {
+		rng = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	if cfg.tier > 0 && cfg.tier < len(words) {
+		words = words[:cfg.tier]
+	}
+
+	if len(cfg.drill) > 0 {
+		words = drillWords(words, cfg.drill)
+	}
+
+	var pick func() int
+	if cfg.tier > 0 {
+		pick = frequencyPicker(len(words), rng)
+	}
+
+	shuffled := make([]string, len(words))
+	copy(shuffled, words)
+
+	rng.Shuffle(len(shuffled), func(i int, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	var candidates []string
+	for _, w := range shuffled {
+		if slices.ContainsFunc(cfg.focus, func(c string) bool { return strings.Contains(w, c) }) {
+			candidates = append(candidates, w)
+		}
+	}
+
+	selection := make([]string, cfg.words)
+	for i := range selection {
+		if len(candidates) > 0 && i%2 == 0 {
+			selection[i] = candidates[rng.Intn(len(candidates))]
+		} else if pick != nil {
+			// Picking the same word twice in a row is likely in a short
+			// pool, so it gets one more chance to be something else.
+			selection[i] = words[pick()]
+			if i > 0 && selection[i] == selection[i-1] {
+				selection[i] = words[pick()]
+			}
+		} else {
+			selection[i] = shuffled[i%len(shuffled)]
+		}
+	}
+
+	if cfg.numbers > 0 {
+		selection = addNumbers(selection, cfg.numbers, rng)
+	}
+
+	if cfg.punctuation > 0 {
+		selection = punctuate(selection, cfg.punctuation, rng)
+	}
+
+	return strings.Join(selection, " ")
+}
+
+// Get a function that picks the index of a word from a list of n words, most
+// common first, with each word as likely as its frequency in real text would
+// suggest (roughly inverse to its rank).
+func frequencyPicker(n int, rng *rand.Rand) func() int {
+	cumulative := make([]float64, n)
+	total := 0.0
+	for i := range cumulative {
+		total += 1 / float64(i+1+rankOffset)
+		cumulative[i] = total
+	}
+
+	return func() int {
+		return min(sort.SearchFloat64s(cumulative, rng.Float64()*total), n-1)
+	}
+}
+
+// Get the words that contain at least one of the drill strings, ignoring case.
+func drillWords(words []string, drill []string) []string {
+	var matches []string
+	for _, w := range words {
+		for _, d := range drill {
+			if strings.Contains(strings.ToLower(w), strings.ToLower(d)) {
+				matches = append(matches, w)
+				break
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return drill
+	}
+
+	return matches
+}
+
+// Language saved with results of tests on text supplied by the user, so they
+// are kept apart from tests on word lists.
+const textLanguage = "text"
+
+// Builds a prompt from text supplied by the user, with every run of whitespace
+// turned into a single space. In timed tests, the text is repeated until there
+// are at least as many words to type as in a generated prompt.
+func literalPrompt(text string, timed bool, words int) string {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	prompt := fields
+	for timed && len(prompt) < words {
+		prompt = append(prompt, fields...)
+	}
+
+	return strings.Join(prompt, " ")
+}
+
+// Turns a list of words into sentences by adding punctuation after some of
+// the words and capitalizing the word that starts each sentence. Now and then
+// a word is quoted too.
+func punctuate(words []string, density float64, rng *rand.Rand) []string {
+	result := make([]string, len(words))
+	capitalize := true
+
+	for i, w := range words {
+		if capitalize {
+			w = capitalizeWord(w)
+			capitalize = false
+		}
+
+		if rng.Float64() < density*quoteShare {
+			w = `"` + w + `"`
+		}
+
+		if i == len(words)-1 {
+			w += "."
+		} else if rng.Float64() < density {
+			mark := pickMark(rng)
+			w += mark
+			capitalize = mark != "," && mark != ";"
+		}
+
+		result[i] = w
+	}
+
+	return result
+}
+
+// Replace some of the words with random numbers of up to maxDigits digits,
+// shorter ones being as likely as longer ones.
+func addNumbers(words []string, chance float64, rng *rand.Rand) []string {
+	result := make([]string, len(words))
+	for i, w := range words {
+		if rng.Float64() < chance {
+			digits := 1 + rng.Intn(maxDigits)
+			w = strconv.Itoa(rng.Intn(9*pow10(digits-1)) + pow10(digits-1))
+		}
+
+		result[i] = w
+	}
+
+	return result
+}
+
+// Get 10 to the power of n.
+func pow10(n int) int {
+	p := 1
+	for range n {
+		p *= 10
+	}
+
+	return p
+}
+
+// Pick a punctuation mark, favoring the common ones.
+func pickMark(rng *rand.Rand) string {
+	total := 0
+	for _, p := range punctuationMarks {
+		total += p.weight
+	}
+
+	n := rng.Intn(total)
+	for _, p := range punctuationMarks {
+		if n < p.weight {
+			return p.mark
+		}
+
+		n -= p.weight
+	}
+
+	return punctuationMarks[0].mark
+}
+
+// Make the first letter of a word uppercase.
+func capitalizeWord(w string) string {
+	r, size := utf8.DecodeRuneInString(w)
+	return string(unicode.ToUpper(r)) + w[size:]
+}
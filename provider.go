@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version of the drill provider protocol, sent with every request so
+// providers can tell if it changes.
+const providerVersion = 1
+
+// How long a drill provider has to answer before the test starts without it.
+const providerTimeout = 10 * time.Second
+
+// Number of items sent in each list of weak spots.
+const providerItems = 50
+
+// Represents what a drill provider is sent on stdin: the user's weak spots,
+// worst first, for it to write a prompt that practices them.
+type providerRequest struct {
+	Version    int              `json:"version"`
+	Language   string           `json:"language"`   // Word list the user picked
+	Words      int              `json:"words"`      // Rough number of words wanted
+	Bigrams    []providerBigram `json:"bigrams"`    // Slowest pairs of characters
+	Characters []providerMiss   `json:"characters"` // Most mistyped characters
+	MissedWord []providerMiss   `json:"words_missed"`
+}
+
+// Represents how fast a pair of characters is typed.
+type providerBigram struct {
+	Text    string  `json:"text"`
+	Count   int     `json:"count"`   // Times the pair was typed correctly
+	Average float64 `json:"average"` // Average time between the two keys, in milliseconds
+}
+
+// Represents how often a character or word is mistyped.
+type providerMiss struct {
+	Text     string `json:"text"`
+	Attempts int    `json:"attempts"`
+	Misses   int    `json:"misses"`
+}
+
+// Represents what a drill provider writes to stdout.
+type providerResponse struct {
+	Text  string `json:"text"`  // Prompt to type
+	Error string `json:"error"` // Why there's no prompt, if there isn't one
+}
+
+// Get the names of every drill provider, sorted.
+func providerNames(providers map[string][]string) []string {
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// Get the command of a drill provider from the config file by name.
+func lookupProvider(providers map[string][]string, name string) ([]string, error) {
+	command, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown provider: %v (expected one of: %v)", name, strings.Join(providerNames(providers), ", "))
+	}
+
+	if len(command) == 0 {
+		return nil, fmt.Errorf("provider %v has no command", name)
+	}
+
+	return command, nil
+}
+
+// Get a prompt from the drill provider in the settings, sending it the weak
+// spots saved in dir.
+func runProvider(settings Settings, dir string) (string, error) {
+	command, err := lookupProvider(settings.providers, settings.provider)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := newProviderRequest(settings, dir)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), providerTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("provider took longer than %v", providerTimeout)
+		}
+
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("provider failed: %v", msg)
+		}
+
+		return "", fmt.Errorf("provider failed: %v", err)
+	}
+
+	var resp providerResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("failed to parse provider output: %v", err)
+	}
+
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+
+	text := strings.Join(strings.Fields(sanitize(resp.Text)), " ")
+	if text == "" {
+		return "", errors.New("provider returned no text")
+	}
+
+	return text, nil
+}
+
+// Collect the weak spots saved in dir to send to a drill provider.
+func newProviderRequest(settings Settings, dir string) (providerRequest, error) {
+	w, err := loadWeakSpots(dir)
+	if err != nil {
+		return providerRequest{}, err
+	}
+
+	p, err := loadProficiency(dir)
+	if err != nil {
+		return providerRequest{}, err
+	}
+
+	words := promptWordsDefault
+	if settings.mode == WORD_COUNT && settings.words > 0 {
+		words = settings.words
+	}
+
+	req := providerRequest{
+		Version:    providerVersion,
+		Language:   settings.language,
+		Words:      words,
+		Bigrams:    []providerBigram{},
+		Characters: []providerMiss{},
+		MissedWord: []providerMiss{},
+	}
+
+	for pair, stat := range w.Bigrams {
+		if stat.Count >= minWeakAttempts {
+			req.Bigrams = append(req.Bigrams, providerBigram{
+				Text:    pair,
+				Count:   stat.Count,
+				Average: stat.Seconds / float64(stat.Count) * 1000,
+			})
+		}
+	}
+
+	sort.Slice(req.Bigrams, func(i int, j int) bool {
+		return req.Bigrams[i].Average > req.Bigrams[j].Average
+	})
+
+	for s, c := range p {
+		if c.Misses > 0 {
+			req.Characters = append(req.Characters, providerMiss{s, c.Attempts, c.Misses})
+		}
+	}
+
+	for word, stat := range w.Words {
+		if stat.Attempts >= minWeakAttempts && stat.Misses > 0 {
+			req.MissedWord = append(req.MissedWord, providerMiss{word, stat.Attempts, stat.Misses})
+		}
+	}
+
+	sortMisses(req.Characters)
+	sortMisses(req.MissedWord)
+
+	req.Bigrams = req.Bigrams[:min(providerItems, len(req.Bigrams))]
+	req.Characters = req.Characters[:min(providerItems, len(req.Characters))]
+	req.MissedWord = req.MissedWord[:min(providerItems, len(req.MissedWord))]
+
+	return req, nil
+}
+
+// Sort characters or words by how often they're mistyped, worst first.
+func sortMisses(misses []providerMiss) {
+	rate := func(m providerMiss) float64 {
+		return float64(m.Misses) / float64(m.Attempts)
+	}
+
+	sort.Slice(misses, func(i int, j int) bool {
+		return rate(misses[i]) > rate(misses[j])
+	})
+}
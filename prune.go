@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Name of the file that stores daily summaries of deleted results.
+const summariesFile = "summaries.jsonl"
+
+// Represents how much history to keep. Zero values mean no limit.
+type Retention struct {
+	KeepResults int  `toml:"keep_results"` // Number of most recent results to keep
+	KeepMonths  int  `toml:"keep_months"`  // Delete results older than this many months
+	Summarize   bool `toml:"summarize"`    // Save daily summaries of deleted results
+}
+
+// Represents every result of a single day, kept after the results themselves
+// have been deleted so long-term trends survive pruning.
+type Summary struct {
+	Date     string  `json:"date"`     // Day the results were saved on, e.g. "2025-01-31"
+	Tests    int     `json:"tests"`    // Number of results
+	WPM      float64 `json:"wpm"`      // Average words per minute
+	BestWPM  float64 `json:"best_wpm"` // Highest words per minute
+	Accuracy float64 `json:"accuracy"` // Average accuracy
+	Seconds  float64 `json:"seconds"`  // Total time spent typing
+}
+
+// Report whether any limit is set.
+func (rt Retention) enabled() bool {
+	return rt.KeepResults > 0 || rt.KeepMonths > 0
+}
+
+// Make sure the limits make sense.
+func (rt Retention) validate() error {
+	if rt.KeepResults < 0 || rt.KeepMonths < 0 {
+		return errors.New("history limits can't be negative")
+	}
+
+	return nil
+}
+
+// Split results into the ones to keep and the ones to delete. Personal bests,
+// which incomplete and failed tests can't be, are always kept, so records
+// survive pruning.
+func (rt Retention) apply(results []Result, now time.Time) ([]Result, []Result) {
+	bests := make(map[recordKey]int)
+	for i, r := range results {
+		if r.Incomplete || r.Failed {
+			continue
+		}
+
+		k := r.key()
+		if j, ok := bests[k]; !ok || r.WPM > results[j].WPM {
+			bests[k] = i
+		}
+	}
+
+	cutoff := time.Time{}
+	if rt.KeepMonths > 0 {
+		cutoff = now.AddDate(0, -rt.KeepMonths, 0)
+	}
+
+	var keep, drop []Result
+	for i, r := range results {
+		tooOld := r.Time.Before(cutoff)
+		tooMany := rt.KeepResults > 0 && i < len(results)-rt.KeepResults
+
+		// Keys without a complete result have no best to keep.
+		j, ok := bests[r.key()]
+		if (tooOld || tooMany) && (!ok || j != i) {
+			drop = append(drop, r)
+		} else {
+			keep = append(keep, r)
+		}
+	}
+
+	return keep, drop
+}
+
+// Group results by the day they were saved on.
+func summarize(results []Result) []Summary {
+	days := make(map[string]*Summary)
+	for _, r := range results {
+		date := r.Time.Local().Format(time.DateOnly)
+
+		s, ok := days[date]
+		if !ok {
+			s = &Summary{Date: date}
+			days[date] = s
+		}
+
+		s.merge(Summary{
+			Date:     date,
+			Tests:    1,
+			WPM:      r.WPM,
+			BestWPM:  r.WPM,
+			Accuracy: r.Accuracy,
+			Seconds:  r.Elapsed,
+		})
+	}
+
+	summaries := make([]Summary, 0, len(days))
+	for _, s := range days {
+		summaries = append(summaries, *s)
+	}
+
+	sort.Slice(summaries, func(i int, j int) bool {
+		return summaries[i].Date < summaries[j].Date
+	})
+
+	return summaries
+}
+
+// Combine another summary of the same day into this one.
+func (s *Summary) merge(other Summary) {
+	tests := s.Tests + other.Tests
+	s.WPM = (s.WPM*float64(s.Tests) + other.WPM*float64(other.Tests)) / float64(tests)
+	s.Accuracy = (s.Accuracy*float64(s.Tests) + other.Accuracy*float64(other.Tests)) / float64(tests)
+	s.BestWPM = max(s.BestWPM, other.BestWPM)
+	s.Seconds += other.Seconds
+	s.Tests = tests
+}
+
+// Get every daily summary saved in dir, oldest first.
+func loadSummaries(dir string) ([]Summary, error) {
+	file, err := os.Open(filepath.Join(dir, summariesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	var summaries []Summary
+	decoder := json.NewDecoder(file)
+	for {
+		var s Summary
+		if err := decoder.Decode(&s); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse json: %v", err)
+		}
+
+		summaries = append(summaries, s)
+	}
+
+	return summaries, nil
+}
+
+// Delete the results that fall outside the retention limits from the store,
+// summarizing them in dir first if asked to. Returns the number of
+// results deleted.
+func pruneHistory(store Store, dir string, rt Retention, now time.Time) (int, error) {
+	results, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	keep, drop := rt.apply(results, now)
+	if len(drop) == 0 {
+		return 0, nil
+	}
+
+	if rt.Summarize {
+		summaries, err := loadSummaries(dir)
+		if err != nil {
+			return 0, err
+		}
+
+		days := make(map[string]int)
+		for i, s := range summaries {
+			days[s.Date] = i
+		}
+
+		for _, s := range summarize(drop) {
+			if i, ok := days[s.Date]; ok {
+				summaries[i].merge(s)
+			} else {
+				summaries = append(summaries, s)
+			}
+		}
+
+		sort.Slice(summaries, func(i int, j int) bool {
+			return summaries[i].Date < summaries[j].Date
+		})
+
+		if err := writeLines(filepath.Join(dir, summariesFile), summaries); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := store.Replace(keep); err != nil {
+		return 0, err
+	}
+
+	return len(drop), nil
+}
+
+// Replace a file with one JSON object per line. The new contents are written
+// to a temporary file first, so the old file is left untouched if anything
+// goes wrong.
+func writeLines[T any](name string, values []T) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for _, v := range values {
+		if err := encoder.Encode(v); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode json: %v", err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// Temporary files are only readable by their owner, unlike the history.
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to set permissions: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		return fmt.Errorf("failed to replace file: %v", err)
+	}
+
+	return nil
+}
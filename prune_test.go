@@ -0,0 +1,19 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneDropsIncompleteFirstResult(t *testing.T) {
+	now := time.Now()
+	results := []Result{
+		{Time: now.AddDate(-1, 0, 0), Mode: "time", Duration: 30, WPM: 50, Incomplete: true},
+		{Time: now, Mode: "time", Duration: 60, WPM: 60},
+	}
+
+	keep, drop := Retention{KeepMonths: 1}.apply(results, now)
+	if len(keep) != 1 || len(drop) != 1 || !drop[0].Incomplete {
+		t.Errorf("kept %v and dropped %v results, want the old incomplete one dropped", len(keep), len(drop))
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long after the first press of ESC or ctrl+c a second press quits, when
+// quitting mid-test has to be confirmed.
+const quitWindow = time.Second
+
+// Represents what happens when the user quits in the middle of a test.
+type QuitAction int16
+
+const (
+	SHOW_PARTIAL    QuitAction = iota // Show stats for what was typed so far
+	SAVE_PARTIAL                      // Show the stats and save them as an incomplete result
+	DISCARD_PARTIAL                   // Quit right away, throwing the test away
+)
+
+// Every quit action, in the order they are listed to the user.
+var quitActions = []QuitAction{SHOW_PARTIAL, SAVE_PARTIAL, DISCARD_PARTIAL}
+
+// Get the name of a quit action, as used in the config file.
+func (q QuitAction) String() string {
+	switch q {
+	case SAVE_PARTIAL:
+		return "save"
+	case DISCARD_PARTIAL:
+		return "discard"
+	default:
+		return "show"
+	}
+}
+
+// Get a quit action from its name.
+func parseQuitAction(name string) (QuitAction, error) {
+	for _, q := range quitActions {
+		if q.String() == name {
+			return q, nil
+		}
+	}
+
+	return SHOW_PARTIAL, fmt.Errorf("unknown quit action: %v", name)
+}
+
+// Allows the quit action to be read from the config file by name.
+func (q *QuitAction) UnmarshalText(text []byte) error {
+	action, err := parseQuitAction(string(text))
+	*q = action
+	return err
+}
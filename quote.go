@@ -0,0 +1,119 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/rivo/uniseg"
+)
+
+// Quotes built into the program, used by quote mode.
+//
+//go:embed quotes/english.json
+var builtinQuotes []byte
+
+// Represents a passage typed in quote mode.
+type Quote struct {
+	Text   string `json:"text"`
+	Author string `json:"author"`
+	Source string `json:"source"` // Work the quote is taken from
+}
+
+// Represents how long a quote is.
+type QuoteLength int16
+
+const (
+	ANY_LENGTH QuoteLength = iota // Quotes of every length
+	SHORT                         // Up to 100 characters
+	MEDIUM                        // 101 to 300 characters
+	LONG                          // 301 to 600 characters
+	THICC                         // More than 600 characters
+)
+
+// Every quote length, in the order they are listed to the user.
+var quoteLengths = []QuoteLength{ANY_LENGTH, SHORT, MEDIUM, LONG, THICC}
+
+// Get the name of a quote length, as used on the command line.
+func (l QuoteLength) String() string {
+	switch l {
+	case SHORT:
+		return "short"
+	case MEDIUM:
+		return "medium"
+	case LONG:
+		return "long"
+	case THICC:
+		return "thicc"
+	default:
+		return "any"
+	}
+}
+
+// Get a quote length from its name.
+func parseQuoteLength(name string) (QuoteLength, error) {
+	for _, l := range quoteLengths {
+		if l.String() == name {
+			return l, nil
+		}
+	}
+
+	return ANY_LENGTH, fmt.Errorf("unknown quote length: %v", name)
+}
+
+// Allows the quote length to be read from the config file by name.
+func (l *QuoteLength) UnmarshalText(text []byte) error {
+	length, err := parseQuoteLength(string(text))
+	*l = length
+	return err
+}
+
+// Report whether a quote falls within the length.
+func (l QuoteLength) fits(q Quote) bool {
+	n := uniseg.GraphemeClusterCount(q.Text)
+
+	switch l {
+	case SHORT:
+		return n <= 100
+	case MEDIUM:
+		return n > 100 && n <= 300
+	case LONG:
+		return n > 300 && n <= 600
+	case THICC:
+		return n > 600
+	default:
+		return true
+	}
+}
+
+// Pick a random built-in quote of the given length.
+func randomQuote(length QuoteLength) (Quote, error) {
+	var quotes []Quote
+	if err := json.Unmarshal(builtinQuotes, &quotes); err != nil {
+		return Quote{}, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	var candidates []Quote
+	for _, q := range quotes {
+		if length.fits(q) {
+			candidates = append(candidates, q)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Quote{}, errors.New("no quotes of that length")
+	}
+
+	return candidates[rand.Intn(len(candidates))], nil
+}
+
+// Get who said a quote, and where, e.g. "Jane Austen, Pride and Prejudice".
+func (q Quote) attribution() string {
+	if q.Source == "" {
+		return q.Author
+	}
+
+	return fmt.Sprintf("%v, %v", q.Author, q.Source)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Identifies the kind of test a personal best was set in. Results are only
+// compared against other results with the same key.
+type recordKey struct {
+	mode       string
+	duration   int
+	words      int
+	language   string
+	difficulty string
+	layout     string
+	keyboard   string
+}
+
+// Get the kind of test a result was set in. Results saved before these fields
+// existed were all normal timed tests in English.
+func (r Result) key() recordKey {
+	k := recordKey{
+		mode:       r.Mode,
+		duration:   r.Duration,
+		language:   r.Language,
+		difficulty: r.Difficulty,
+		layout:     r.Layout,
+		keyboard:   r.Keyboard,
+	}
+
+	if k.mode == "" {
+		k.mode = "time"
+	}
+
+	// Word count tests of different lengths aren't comparable, just like
+	// timed tests of different durations.
+	if k.mode == WORD_COUNT.String() && r.Settings != nil {
+		k.words = r.Settings.Words
+	}
+
+	if k.language == "" {
+		k.language = languageDefault
+	}
+
+	if k.difficulty == "" {
+		k.difficulty = "normal"
+	}
+
+	return k
+}
+
+// Get the best result for every kind of test the user has taken, sorted by
+// mode, language, difficulty, duration, and then the keyboard it was typed on.
+func personalBests(results []Result) []Result {
+	bests := make(map[recordKey]Result)
+	for _, r := range results {
+		if r.Incomplete || r.Failed {
+			continue
+		}
+
+		k := r.key()
+		if best, ok := bests[k]; !ok || r.WPM > best.WPM {
+			bests[k] = r
+		}
+	}
+
+	records := make([]Result, 0, len(bests))
+	for _, r := range bests {
+		records = append(records, r)
+	}
+
+	sort.Slice(records, func(i int, j int) bool {
+		a, b := records[i].key(), records[j].key()
+		if a.mode != b.mode {
+			return a.mode < b.mode
+		}
+
+		if a.language != b.language {
+			return a.language < b.language
+		}
+
+		if a.difficulty != b.difficulty {
+			return a.difficulty < b.difficulty
+		}
+
+		if a.duration != b.duration {
+			return a.duration < b.duration
+		}
+
+		if a.words != b.words {
+			return a.words < b.words
+		}
+
+		if a.layout != b.layout {
+			return a.layout < b.layout
+		}
+
+		return a.keyboard < b.keyboard
+	})
+
+	return records
+}
+
+// Get the best result for a kind of test, if the user has taken it before.
+func personalBest(results []Result, k recordKey) (Result, bool) {
+	var best Result
+	found := false
+
+	for _, r := range results {
+		if !r.Incomplete && !r.Failed && r.key() == k && (!found || r.WPM > best.WPM) {
+			best = r
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// Render the table of personal bests.
+func (m Model) recordsView() string {
+	s := "Personal bests\n\n"
+
+	if len(m.records) == 0 {
+		return s + "No results yet. Finish a test to set your first record!\n"
+	}
+
+	// Only show the keyboard columns once the user has saved results with them.
+	showKeyboard := false
+	for _, r := range m.records {
+		if r.Layout != "" || r.Keyboard != "" {
+			showKeyboard = true
+		}
+	}
+
+	s += fmt.Sprintf(
+		"%-10s %-9s %-10s %-11s %8s %9s  %-10s",
+		"Mode", "Duration", "Language", "Difficulty", "WPM", "Accuracy", "Date",
+	)
+
+	if showKeyboard {
+		s += fmt.Sprintf(" %-10s %s", "Layout", "Keyboard")
+	}
+
+	s += "\n"
+
+	for _, r := range m.records {
+		k := r.key()
+
+		duration := "-"
+		if k.duration > 0 {
+			duration = fmt.Sprintf("%vs", k.duration)
+		} else if k.words > 0 {
+			duration = fmt.Sprintf("%v words", k.words)
+		}
+
+		s += fmt.Sprintf(
+			"%-10s %-9s %-10s %-11s %8.2f %8.2f%%  %-10s",
+			k.mode,
+			duration,
+			k.language,
+			k.difficulty,
+			r.WPM,
+			r.Accuracy,
+			r.Time.Local().Format("2006-01-02"),
+		)
+
+		if showKeyboard {
+			s += fmt.Sprintf(" %-10s %s", orDash(k.layout), orDash(k.keyboard))
+		}
+
+		s += "\n"
+	}
+
+	return s
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Seconds a notice stays on screen.
+const toastSeconds = 3
+
+// Keeps track of when files were last changed, so they can be reloaded.
+type watcher struct {
+	modified map[string]time.Time // Modification time of each file, zero if missing
+}
+
+// Start watching the given files.
+func newWatcher(paths ...string) *watcher {
+	w := &watcher{modified: make(map[string]time.Time)}
+	for _, path := range paths {
+		w.modified[path] = modTime(path)
+	}
+
+	return w
+}
+
+// Get the files that changed since the last check.
+func (w *watcher) changed() []string {
+	var paths []string
+	for path, before := range w.modified {
+		if after := modTime(path); !after.Equal(before) {
+			w.modified[path] = after
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// Get when a file was last changed, or the zero time if it doesn't exist.
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return info.ModTime()
+}
+
+// Get the files that affect how the next test looks: the word lists, the
+// manifests and sentences of language folders, and the config file.
+func watchedFiles(settings Settings) []string {
+	var paths []string
+	if !settings.builtinWords {
+		for _, language := range strings.Split(settings.language, languageSeparator) {
+			paths = append(paths, wordsPath(language))
+			if isLanguageDir(language) {
+				paths = append(paths, filepath.Join(languageDir(language), manifestFile))
+				paths = append(paths, filepath.Join(languageDir(language), sentencesFile))
+			}
+		}
+	}
+
+	if settings.configDir != "" {
+		paths = append(paths, filepath.Join(settings.configDir, configFile))
+	}
+
+	return paths
+}
+
+// Report whether the user is between tests, so files can be reloaded without
+// changing a test that is underway.
+func (m Model) idle() bool {
+	switch m.view {
+	case MENU:
+		return true
+	case PROMPT:
+		return m.test.State() == engine.READY
+	default:
+		return false
+	}
+}
+
+// Reload the word lists and the config file's colors if they changed, and
+// say so with a short notice.
+func (m Model) reload() Model {
+	if m.watch == nil {
+		return m
+	}
+
+	var words, config bool
+	for _, path := range m.watch.changed() {
+		if filepath.Base(path) == configFile {
+			config = true
+		} else {
+			words = true
+		}
+	}
+
+	var reloaded []string
+	if config {
+		cfg, err := loadConfig(m.settings.configDir)
+		if err != nil {
+			return m.notify(fmt.Sprintf("Failed to reload config: %v", err))
+		}
+
+		theme, _ := lookupTheme(m.settings.themeName)
+		m.settings.theme = cfg.Colors.apply(theme)
+		reloaded = append(reloaded, "colors")
+	}
+
+	if words {
+		if _, err := loadWords(m.settings); err != nil {
+			return m.notify(fmt.Sprintf("Failed to reload word list: %v", err))
+		}
+
+		next := newModel(m.settings, m.home, m.user)
+		next.test.SetLimit(m.test.Limit())
+		next.menu = m.menu
+		next.view = m.view
+		m = next
+		reloaded = append(reloaded, "word list")
+	}
+
+	if len(reloaded) == 0 {
+		return m
+	}
+
+	// Only the colors are taken from the config file mid-session, since the
+	// other settings may have been overridden by flags.
+	message := fmt.Sprintf("Reloaded %v", strings.Join(reloaded, " and "))
+	if config {
+		message += "; other config changes apply the next time the program starts"
+	}
+
+	return m.notify(message)
+}
+
+// Show a short notice.
+func (m Model) notify(message string) Model {
+	m.toast = message
+	m.toastLeft = max(int(toastSeconds*time.Second/tickInterval), 1)
+	return m
+}
+
+// Render the current notice, if there is one.
+func (m Model) toastView() string {
+	if m.toastLeft <= 0 {
+		return ""
+	}
+
+	return "\n\n" + m.settings.theme.accent.Render(m.toast)
+}
@@ -0,0 +1,20 @@
+package main
+
+import "time"
+
+// How often the clock ticks in remote mode, where every redraw has to cross
+// the connection.
+const remoteTickInterval = 2 * time.Second
+
+// Most times a second the screen is redrawn in remote mode. Changes made in
+// between are sent together in one redraw.
+const remoteFPS = 20
+
+// Tune the program for a slow connection, e.g. over SSH: the clock ticks less
+// often, animations are skipped, and redraws are batched. Time still runs out
+// on time, since keys pressed after it are never counted.
+func useRemote(settings Settings) Settings {
+	tickInterval = remoteTickInterval
+	settings.reducedMotion = true
+	return settings
+}
@@ -0,0 +1,28 @@
+package main
+
+import "time"
+
+// Shortest time between two presses of the same key a person can manage.
+// Anything faster is the operating system repeating a held key.
+const repeatInterval = 50 * time.Millisecond
+
+// Detects keys repeated by the operating system while they are held down.
+type repeatFilter struct {
+	last    rune      // Character of the latest keystroke
+	at      time.Time // When the latest keystroke arrived
+	dropped int       // Keystrokes ignored as repeats
+}
+
+// Report whether a keystroke is a repeat of the previous one, and should be
+// ignored. Every keystroke of a burst counts as a repeat, however long the key
+// is held.
+func (f *repeatFilter) repeated(c rune, now time.Time) bool {
+	repeat := c == f.last && now.Sub(f.at) < repeatInterval
+	f.last, f.at = c, now
+
+	if repeat {
+		f.dropped++
+	}
+
+	return repeat
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// How often the replay moves forward.
+const replayInterval = 30 * time.Millisecond
+
+// Speeds the replay can be played at, slowest first.
+var replaySpeeds = []float64{0.25, 0.5, 1, 2, 4}
+
+// Index of the speed the replay starts at (1x).
+const replaySpeedDefault = 2
+
+type replayMsg time.Time
+
+// Represents a finished test being played back, key by key, on a copy of the
+// test driven by a clock of its own.
+type replay struct {
+	test    *engine.Engine
+	clock   *engine.ManualClock
+	presses []engine.Event // Keystrokes and erasures to play, in order
+	start   time.Time      // When the first key was pressed in the test
+	next    int            // Index of the next press to play
+	at      time.Duration  // How far into the test the replay is
+	speed   int            // Index of the speed in replaySpeeds
+	paused  bool
+}
+
+// Get a replay of the test from the start.
+func (m Model) newReplay(speed int) *replay {
+	clock := engine.NewManualClock(m.recorder.start)
+	test := engine.New(m.test.Prompt(), 0, clock)
+	if m.test.Free() {
+		test = engine.NewFree(0, clock)
+	}
+
+	test.SetForgive(m.settings.forgiveErased)
+
+	return &replay{
+		test:    test,
+		clock:   clock,
+		presses: m.recorder.presses,
+		start:   m.recorder.start,
+		speed:   speed,
+	}
+}
+
+// Get the command that moves the replay forward.
+func nextReplayFrame() tea.Cmd {
+	return tea.Tick(replayInterval, func(t time.Time) tea.Msg {
+		return replayMsg(t)
+	})
+}
+
+// Report whether every press has been played.
+func (r *replay) done() bool {
+	return r.next == len(r.presses)
+}
+
+// Move the replay forward by d of real time, playing every press that
+// happened in the test by then.
+func (r *replay) advance(d time.Duration) {
+	r.at += time.Duration(float64(d) * replaySpeeds[r.speed])
+	now := r.start.Add(r.at)
+
+	for ; !r.done(); r.next++ {
+		switch p := r.presses[r.next].(type) {
+		case engine.KeystrokeScored:
+			if p.Time.After(now) {
+				return
+			}
+
+			r.clock.Set(p.Time)
+			r.test.TypeGrapheme(p.Typed)
+		case engine.KeystrokeErased:
+			if p.Time.After(now) {
+				return
+			}
+
+			r.clock.Set(p.Time)
+			r.test.Backspace()
+		}
+	}
+
+	r.clock.Set(now)
+}
+
+// Manages the replay screen. Space pauses, + and - change the speed, R starts
+// over, and ESC or Q goes back to the stats.
+func (m Model) updateReplay(msg tea.Msg) (tea.Model, tea.Cmd) {
+	r := m.replay
+
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case replayMsg:
+		if r.paused || r.done() {
+			return m, nil
+		}
+
+		r.advance(replayInterval)
+		if r.done() {
+			return m, nil
+		}
+
+		return m, nextReplayFrame()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q":
+			m.replay = nil
+			m.view = STATS
+
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case " ":
+			r.paused = !r.paused
+			if !r.paused && !r.done() {
+				return m, nextReplayFrame()
+			}
+
+		case "+", "=":
+			r.speed = min(r.speed+1, len(replaySpeeds)-1)
+
+		case "-":
+			r.speed = max(r.speed-1, 0)
+
+		case "r":
+			m.replay = m.newReplay(r.speed)
+			return m, nextReplayFrame()
+		}
+	}
+
+	return m, nil
+}
+
+// Render the test as it stood at this point of the replay.
+func (m Model) replayView() string {
+	r := m.replay
+	accent := m.settings.theme.accent
+
+	state := fmt.Sprintf("%vx", replaySpeeds[r.speed])
+	switch {
+	case r.done():
+		state = "done"
+	case r.paused:
+		state = "paused"
+	}
+
+	at := min(r.at, m.test.Elapsed())
+	s := fmt.Sprintf(
+		"Replay (%v) | %v | %v WPM\n\n",
+		state,
+		accent.Render(fmt.Sprintf("%.1fs", at.Seconds())),
+		accent.Render(fmt.Sprintf("%.0f", r.test.Score().WPM)),
+	)
+
+	// The prompt is drawn as usual, from the copy of the test being replayed.
+	shown := m
+	shown.test = r.test
+	s += m.direction.isolate(shown.promptView())
+
+	s += "\n\nSpace to pause, +/- to change the speed, R to start over, or ESC to go back"
+	return s
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+	"github.com/rivo/uniseg"
+)
+
+// Version of the report format. Increase it whenever a field is renamed,
+// removed, or changes meaning; adding a field doesn't need a new version.
+const reportVersion = 1
+
+// Represents everything known about a single test, as written for other
+// programs to read. This is the one format results leave the program in.
+type Report struct {
+	Version    int               `json:"version"`              // Version of the report format
+	Summary    Result            `json:"summary"`              // Outcome of the test, as saved in the history
+	Words      []WordReport      `json:"words,omitempty"`      // Every word the user finished, in order
+	Keystrokes []KeystrokeReport `json:"keystrokes,omitempty"` // Every character typed, in order
+	Samples    []Sample          `json:"samples,omitempty"`    // Speed at the end of every second
+	Settings   TestSettings      `json:"settings"`             // Settings the test was taken with
+}
+
+// Represents a single word of the prompt, as typed.
+type WordReport struct {
+	Index    int     `json:"index"`    // Position of the word in the prompt, starting at 0
+	Word     string  `json:"word"`     // Word the prompt asked for
+	Typed    string  `json:"typed"`    // What the user typed in its place
+	Correct  bool    `json:"correct"`  // Whether every character was typed correctly
+	Finished float64 `json:"finished"` // Seconds into the test the word was finished
+	Duration float64 `json:"duration"` // Seconds spent typing the word
+	WPM      float64 `json:"wpm"`      // Speed the word was typed at
+}
+
+// Represents a single character typed, for analyzing how the user types key by
+// key.
+type KeystrokeReport struct {
+	Position   int     `json:"position"`   // Index of the character in the prompt, starting at 0
+	Expected   string  `json:"expected"`   // Character the prompt asked for
+	Typed      string  `json:"typed"`      // Character the user typed
+	Time       float64 `json:"time"`       // Seconds into the test the key was pressed
+	Correct    bool    `json:"correct"`    // Whether the two match
+	Correction bool    `json:"correction"` // Whether it was typed over an earlier mistake
+	Erased     bool    `json:"erased"`     // Whether it was erased and typed over later
+}
+
+// Represents the user's progress at the end of a single second of the test.
+type Sample struct {
+	Second   int     `json:"second"`   // Seconds into the test, starting at 1
+	WPM      float64 `json:"wpm"`      // Words per minute so far, excluding mistakes
+	Raw      float64 `json:"raw"`      // Words per minute during this second, including mistakes
+	Mistakes int     `json:"mistakes"` // Mistakes made during this second
+}
+
+// Keeps the events of a test needed to write a report about it.
+type recorder struct {
+	words      []engine.WordCompleted // Words finished so far
+	keystrokes []engine.KeystrokeScored
+	presses    []engine.Event // Keystrokes and erasures, in order, for replaying the test
+	start      time.Time
+	paused     time.Duration // Time spent paused so far
+}
+
+// Keeps track of the words and keystrokes of the test. Times are shifted back
+// by the time spent paused, so pauses don't show up in the report.
+func (rec *recorder) observe(ev engine.Event) {
+	switch ev := ev.(type) {
+	case engine.TestStarted:
+		rec.start = ev.Time
+	case engine.TestResumed:
+		rec.paused = ev.Paused
+	case engine.KeystrokeScored:
+		ev.Time = ev.Time.Add(-rec.paused)
+		rec.keystrokes = append(rec.keystrokes, ev)
+		rec.presses = append(rec.presses, ev)
+	case engine.KeystrokeErased:
+		ev.Time = ev.Time.Add(-rec.paused)
+		rec.presses = append(rec.presses, ev)
+	case engine.WordCompleted:
+		ev.Time = ev.Time.Add(-rec.paused)
+		rec.words = append(rec.words, ev)
+	}
+}
+
+// Get the report of the test taken in m.
+func (m Model) report() Report {
+	report := summaryReport(m.result())
+	report.Words = m.recorder.wordReports()
+	report.Keystrokes = m.recorder.keystrokeReports()
+	report.Samples = m.recorder.samples(m.test.Elapsed())
+
+	if m.settings.anonymize {
+		report = report.anonymized()
+	}
+
+	return report
+}
+
+// Get a report with only the summary, for results loaded from the history.
+// Results saved before settings were recorded with them only have the
+// settings that are part of the result itself.
+func summaryReport(r Result) Report {
+	settings := TestSettings{
+		Mode:     r.Mode,
+		Duration: r.Duration,
+		Language: r.Language,
+		Layout:   r.Layout,
+		Keyboard: r.Keyboard,
+		Level:    r.Level,
+	}
+
+	if r.Settings != nil {
+		settings = *r.Settings
+	}
+
+	return Report{
+		Version:  reportVersion,
+		Summary:  r,
+		Settings: settings,
+	}
+}
+
+// Get the words finished during the test, timed from the end of the previous
+// word.
+func (rec *recorder) wordReports() []WordReport {
+	var words []WordReport
+
+	last := rec.start
+	for _, w := range rec.words {
+		duration := w.Time.Sub(last)
+		last = w.Time
+
+		wpm := 0.0
+		if duration > 0 {
+			wpm = float64(uniseg.GraphemeClusterCount(w.Typed)+1) / 5 / duration.Minutes()
+		}
+
+		words = append(words, WordReport{
+			Index:    w.Index,
+			Word:     w.Word,
+			Typed:    w.Typed,
+			Correct:  w.Correct,
+			Finished: w.Time.Sub(rec.start).Seconds(),
+			Duration: duration.Seconds(),
+			WPM:      wpm,
+		})
+	}
+
+	return words
+}
+
+// Get every character typed during the test. A character is erased if another
+// one was typed in its place later, and a correction if one in its place was a
+// mistake.
+func (rec *recorder) keystrokeReports() []KeystrokeReport {
+	var keystrokes []KeystrokeReport
+
+	last := make(map[int]int)      // Index of the last keystroke at each position
+	mistyped := make(map[int]bool) // Positions typed incorrectly so far
+	for _, k := range rec.keystrokes {
+		if i, ok := last[k.Position]; ok {
+			keystrokes[i].Erased = true
+		}
+
+		last[k.Position] = len(keystrokes)
+		keystrokes = append(keystrokes, KeystrokeReport{
+			Position:   k.Position,
+			Expected:   k.Expected,
+			Typed:      k.Typed,
+			Time:       k.Time.Sub(rec.start).Seconds(),
+			Correct:    k.Correct,
+			Correction: mistyped[k.Position],
+		})
+
+		if !k.Correct {
+			mistyped[k.Position] = true
+		}
+	}
+
+	return keystrokes
+}
+
+// Get a copy of the report without the text of the prompt, for sharing with
+// others. Letters become x (X when capital) and digits become 0, so the shape
+// of the text and which keys were mistyped are kept, but not what it said.
+func (r Report) anonymized() Report {
+	r.Words = slices.Clone(r.Words)
+	for i := range r.Words {
+		r.Words[i].Word = anonymize(r.Words[i].Word)
+		r.Words[i].Typed = anonymize(r.Words[i].Typed)
+	}
+
+	r.Keystrokes = slices.Clone(r.Keystrokes)
+	for i := range r.Keystrokes {
+		r.Keystrokes[i].Expected = anonymize(r.Keystrokes[i].Expected)
+		r.Keystrokes[i].Typed = anonymize(r.Keystrokes[i].Typed)
+	}
+
+	r.Settings.Quote = ""
+	if r.Summary.Settings != nil {
+		settings := *r.Summary.Settings
+		settings.Quote = ""
+		r.Summary.Settings = &settings
+	}
+
+	return r
+}
+
+// Hide what text says, keeping only what kind of character each one is.
+func anonymize(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsUpper(r):
+			return 'X'
+		case unicode.IsLetter(r):
+			return 'x'
+		case unicode.IsDigit(r):
+			return '0'
+		default:
+			return r
+		}
+	}, text)
+}
+
+// Get the user's speed at the end of every second of the test.
+func (rec *recorder) samples(elapsed time.Duration) []Sample {
+	var samples []Sample
+
+	seconds := int((elapsed + time.Second - 1) / time.Second)
+	correct := 0
+	i := 0
+
+	for second := 1; second <= seconds; second++ {
+		end := rec.start.Add(time.Duration(second) * time.Second)
+		typed, mistakes := 0, 0
+
+		for ; i < len(rec.keystrokes) && rec.keystrokes[i].Time.Before(end); i++ {
+			typed++
+			if rec.keystrokes[i].Correct {
+				correct++
+			} else {
+				mistakes++
+			}
+		}
+
+		samples = append(samples, Sample{
+			Second:   second,
+			WPM:      float64(correct) / 5 / (float64(second) / 60),
+			Raw:      float64(typed) / 5 * 60,
+			Mistakes: mistakes,
+		})
+	}
+
+	return samples
+}
+
+// Write a report, or the report of a playlist, to a file, replacing whatever
+// was there.
+func saveReport(name string, report any) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(name, data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"time"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Get the result scored again with the current formulas, from the keystrokes
+// and time it was saved with. Transcriptions keep their raw speed, since it
+// counts keystrokes that aren't saved. Results saved without any keystrokes,
+// like some imported from older versions, are left as they are.
+func (r Result) rescored() Result {
+	if r.Correct == 0 && r.Mistakes == 0 {
+		return r
+	}
+
+	elapsed := time.Duration(r.Elapsed * float64(time.Second))
+	score := engine.NewScore(r.Correct, r.Mistakes, elapsed)
+
+	r.WPM = score.WPM
+	r.Accuracy = score.Accuracy
+	if r.Mode != TRANSCRIBE.String() {
+		r.Raw = score.Raw
+	}
+
+	r.Scoring = scoringVersion
+	return r
+}
+
+// Score every result in the store again with the current formulas. Returns
+// how many of them changed; the store is only written if any did.
+func rescoreHistory(store Store) (int, error) {
+	results, err := store.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for i, r := range results {
+		next := r.rescored()
+		if next.Scoring != r.Scoring || !sameScore(next.WPM, r.WPM) || !sameScore(next.Raw, r.Raw) || !sameScore(next.Accuracy, r.Accuracy) {
+			changed++
+		}
+
+		results[i] = next
+	}
+
+	if changed == 0 {
+		return 0, nil
+	}
+
+	return changed, store.Replace(results)
+}
+
+// Wraps a store so nothing is written to it, to see what a change would do.
+type dryRunStore struct {
+	Store
+}
+
+func (dryRunStore) Save(r Result) error {
+	return nil
+}
+
+func (dryRunStore) Replace(results []Result) error {
+	return nil
+}
+
+// Report whether two scores are the same, give or take rounding.
+func sameScore(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
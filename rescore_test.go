@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRescoreTwiceChangesNothing(t *testing.T) {
+	for _, backend := range backends {
+		store, err := openStore(backend, t.TempDir())
+		if err != nil {
+			t.Fatalf("%v: failed to open store: %v", backend, err)
+		}
+
+		// Saved before the formulas were versioned, with a speed that's off.
+		r := Result{Time: time.Now(), Mode: "time", Elapsed: 30, WPM: 1, Correct: 150, Mistakes: 5}
+		if err := store.Save(r); err != nil {
+			t.Fatalf("%v: failed to save result: %v", backend, err)
+		}
+
+		if changed, err := rescoreHistory(store); err != nil || changed != 1 {
+			t.Fatalf("%v: first rescore changed %v results (err %v), want 1", backend, changed, err)
+		}
+
+		if changed, err := rescoreHistory(store); err != nil || changed != 0 {
+			t.Errorf("%v: second rescore changed %v results (err %v), want 0", backend, changed, err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// Number of items used from the top of each feed.
+const feedItems = 10
+
+// Matches HTML tags, which feeds often include in their summaries.
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// Represents an RSS or Atom feed. Only one of the two lists is filled in,
+// depending on the format.
+type feed struct {
+	Items   []feedItem `xml:"channel>item"` // RSS
+	Entries []feedItem `xml:"entry"`        // Atom
+}
+
+// Represents a single story in a feed.
+type feedItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"` // RSS
+	Summary     string `xml:"summary"`     // Atom
+}
+
+// Get the most recent stories from every feed, as a headline followed by its
+// summary. Feeds that can't be reached are skipped, unless none can be.
+func headlines(urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("no feeds configured: add some to feeds in the config file")
+	}
+
+	var texts []string
+	var lastErr error
+
+	for _, url := range urls {
+		body, err := get(url)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var f feed
+		if err := xml.Unmarshal(body, &f); err != nil {
+			lastErr = fmt.Errorf("failed to parse feed %v: %v", url, err)
+			continue
+		}
+
+		items := append(f.Items, f.Entries...)
+		for _, item := range items[:min(len(items), feedItems)] {
+			if text := item.text(); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+
+	if len(texts) == 0 {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+
+		return nil, errors.New("feeds have no stories")
+	}
+
+	return texts, nil
+}
+
+// Get the headline and summary of a story as plain text.
+func (item feedItem) text() string {
+	title := stripHTML(item.Title)
+
+	summary := item.Description
+	if summary == "" {
+		summary = item.Summary
+	}
+	summary = stripHTML(summary)
+
+	if title == "" || summary == "" {
+		return title + summary
+	}
+
+	if !strings.ContainsAny(title[len(title)-1:], ".?!") {
+		title += "."
+	}
+
+	return title + " " + summary
+}
+
+// Remove HTML tags and entities from text.
+func stripHTML(text string) string {
+	text = tagPattern.ReplaceAllString(text, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Name of the file the session is kept in while it's underway.
+const sessionFile = "session.json"
+
+// How often the session is written down while a test is taken.
+const autosaveInterval = 5 * time.Second
+
+// Represents a snapshot of the tests being taken, written down as they go so
+// a session cut short by a crash can be picked up where it left off. Tests
+// are saved to the history as they finish, so only what comes next is kept.
+type session struct {
+	Saved    time.Time `json:"saved"`              // When the snapshot was taken
+	User     string    `json:"user,omitempty"`     // Name of the user in multi-user mode
+	Mode     string    `json:"mode"`               // Kind of test being taken
+	Duration int       `json:"duration,omitempty"` // Time limit in seconds, in time mode
+	Words    int       `json:"words,omitempty"`    // Number of words, in word count mode
+	Language string    `json:"language"`           // Name of the word list
+	Text     string    `json:"text,omitempty"`     // Text being typed instead of a generated prompt
+	Chunks   []string  `json:"chunks,omitempty"`   // Parts of the text, typed one test at a time
+	Chunk    int       `json:"chunk,omitempty"`    // Index of the part to type next
+	Playlist string    `json:"playlist,omitempty"` // File of the playlist being taken
+	Step     int       `json:"step,omitempty"`     // Index of the playlist's test to take next
+	Reports  []Report  `json:"reports,omitempty"`  // Reports of the playlist's tests finished so far
+	Unsaved  *Result   `json:"unsaved,omitempty"`  // Result that wasn't saved yet, e.g. of a test cut short
+}
+
+// Read the session that was underway when the program last stopped, if it
+// didn't get to quit on its own.
+func loadSession(home string) (session, bool) {
+	data, err := os.ReadFile(filepath.Join(home, sessionFile))
+	if err != nil {
+		return session{}, false
+	}
+
+	// A snapshot that can't be read is no use to resume, and shouldn't keep
+	// the program from starting.
+	var s session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return session{}, false
+	}
+
+	return s, true
+}
+
+// Write the session to home.
+func (s session) save(home string) error {
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	return writeFile(filepath.Join(home, sessionFile), data, 0o644)
+}
+
+// Forget the session, once the user quits on their own.
+func clearSession(home string) error {
+	err := os.Remove(filepath.Join(home, sessionFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove session: %v", err)
+	}
+
+	return nil
+}
+
+// Report whether the session should be written down: only tests the user
+// takes themselves are worth resuming.
+func (m Model) autosaving() bool {
+	return m.settings.autosave && m.test != nil && (m.view == PROMPT || m.view == STATS)
+}
+
+// Take a snapshot of the session as it stands.
+func (m Model) snapshot() session {
+	s := session{
+		Saved:    time.Now(),
+		User:     m.user,
+		Mode:     m.mode.String(),
+		Language: m.settings.language,
+		Text:     m.settings.text,
+		Chunks:   m.settings.chunks,
+		Chunk:    m.settings.chunk,
+	}
+
+	switch m.mode {
+	case TIME:
+		s.Duration = int(m.test.Limit().Seconds())
+	case WORD_COUNT:
+		s.Words = m.prompt.words
+	}
+
+	pl := m.settings.playlist
+	if pl != nil {
+		s.Playlist = pl.file
+		s.Step = pl.step
+		s.Reports = pl.reports
+	}
+
+	// A test cut short is only kept when partial results are saved, and one
+	// waiting on the user to decide is kept as it is.
+	if m.view == PROMPT && m.test.State() == engine.TYPING && m.settings.onQuit == SAVE_PARTIAL {
+		partial := m
+		partial.incomplete = true
+		r := partial.result()
+		s.Unsaved = &r
+	} else if m.view == STATS && m.confirm {
+		r := m.result()
+		s.Unsaved = &r
+	}
+
+	// The finished test was saved already, so the session picks up at the
+	// one after it.
+	if m.view == STATS && m.nextChunk() {
+		s.Chunk++
+		s.Text = s.Chunks[s.Chunk]
+	} else if m.view == STATS && m.nextTest() {
+		s.Step++
+	}
+
+	return s
+}
+
+// Write down the session, saying so if it fails.
+func (m Model) autosave(now time.Time) Model {
+	m.autosaved = now
+	if err := m.snapshot().save(m.home); err != nil {
+		return m.notify(fmt.Sprintf("Failed to save session: %v", err))
+	}
+
+	return m
+}
+
+// Get the settings the session was taken with, in place of the ones the
+// program was started with.
+func (s session) apply(settings Settings) (Settings, error) {
+	mode, err := parseMode(s.Mode)
+	if err != nil {
+		return settings, err
+	}
+
+	settings.mode = mode
+	settings.duration = s.Duration
+	settings.words = s.Words
+	settings.language = s.Language
+	settings.text = s.Text
+	settings.chunks = s.Chunks
+	settings.chunk = s.Chunk
+	settings.playlist = nil
+
+	if s.Playlist == "" {
+		return settings, nil
+	}
+
+	p, err := loadPlaylist(s.Playlist)
+	if err != nil {
+		return settings, err
+	}
+
+	if s.Step >= len(p.Tests) {
+		return settings, errors.New("playlist has fewer tests than before")
+	}
+
+	pl := &playlist{Playlist: p, file: s.Playlist, base: settings, step: s.Step, reports: s.Reports}
+	return pl.settings(), nil
+}
+
+// Describe the session for the user to decide whether to resume it.
+func (s session) describe() string {
+	d := fmt.Sprintf("a %v test", s.Mode)
+	if s.Playlist != "" {
+		d = fmt.Sprintf("test %v of the playlist %v", s.Step+1, filepath.Base(s.Playlist))
+	} else if len(s.Chunks) > 0 {
+		d = fmt.Sprintf("part %v of %v of the text", s.Chunk+1, len(s.Chunks))
+	}
+
+	if s.User != "" {
+		d += fmt.Sprintf(" as %v", s.User)
+	}
+
+	return d
+}
+
+// Start the session over where it left off, keeping the result that wasn't
+// saved yet.
+func (m Model) resume() Model {
+	s := m.resumable
+	settings, err := s.apply(m.settings)
+	if err != nil {
+		next := m.startFresh()
+		return next.notify(fmt.Sprintf("Couldn't resume the session: %v", err))
+	}
+
+	next := newModel(settings, m.home, s.User)
+	if s.Unsaved != nil && next.store != nil {
+		if err := next.store.Save(*s.Unsaved); err != nil {
+			return next.notify(fmt.Sprintf("Failed to keep the unsaved result: %v", err))
+		}
+	}
+
+	// Written down right away, so the result isn't kept twice.
+	if !next.autosaving() {
+		if err := clearSession(m.home); err != nil {
+			return next.notify(err.Error())
+		}
+
+		return next
+	}
+
+	return next.autosave(time.Now())
+}
+
+// Forget the session and start the way the program was started.
+func (m Model) startFresh() Model {
+	if err := clearSession(m.home); err != nil {
+		return startModel(m.settings, m.home).notify(err.Error())
+	}
+
+	return startModel(m.settings, m.home)
+}
+
+// Asks whether to resume the session left behind the last time the program
+// stopped. Y resumes it, and N or ESC starts over.
+func (m Model) updateResume(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "y", "enter":
+			return m.resume(), nil
+		case "n", "esc":
+			return m.startFresh(), nil
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+	}
+
+	return m, nil
+}
+
+// Render the question of whether to resume the session.
+func (m Model) resumeView() string {
+	s := m.resumable
+	accent := m.settings.theme.accent
+
+	v := fmt.Sprintf(
+		"The last session stopped %v ago, during %v.\n",
+		accent.Render(time.Since(s.Saved).Round(time.Second).String()),
+		s.describe(),
+	)
+
+	if s.Unsaved != nil {
+		v += "Its last result wasn't saved yet, and will be kept if you resume.\n"
+	}
+
+	v += "\nResume it? (y/n)"
+	return v
+}
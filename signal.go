@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Sent when the program is told to stop from outside, e.g. the terminal was
+// closed or it got SIGTERM.
+type terminateMsg struct{}
+
+// Pass signals that stop the program on to it as a message, so a test in
+// progress can be saved before quitting. Takes the place of Bubble Tea's own
+// handler, which quits without telling the model.
+func handleSignals(p *tea.Program) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+
+	go func() {
+		<-signals
+		p.Send(terminateMsg{})
+	}()
+}
+
+// Quit for a signal, saving the test first if the user quits mid-test with
+// partial results saved. A test that was already finished was saved then.
+func (m Model) terminate() (tea.Model, tea.Cmd) {
+	if m.view == PROMPT && m.test.State() == engine.TYPING && m.settings.onQuit == SAVE_PARTIAL {
+		m.incomplete = true
+		m.finish()
+	}
+
+	m.terminated = true
+	if m.autosaving() {
+		m = m.autosave(time.Now())
+	}
+
+	return m, tea.Quit
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Represents where the words of the prompt come from.
+type Source int16
+
+const (
+	WORDLIST   Source = iota // Random words from the word lists
+	WIKIPEDIA                // Summary of a random Wikipedia article
+	RSS                      // Recent headline from the user's news feeds
+	SENTENCES                // Random sentences from the language's sentence corpus
+	NUMBER_ROW               // Digits mixed with their shifted symbols
+)
+
+// Every source, in the order they are listed to the user.
+var sources = []Source{WORDLIST, SENTENCES, WIKIPEDIA, RSS, NUMBER_ROW}
+
+// Get the name of a source, as used on the command line and in saved results.
+func (s Source) String() string {
+	switch s {
+	case WIKIPEDIA:
+		return "wikipedia"
+	case RSS:
+		return "rss"
+	case SENTENCES:
+		return "sentences"
+	case NUMBER_ROW:
+		return "number-row"
+	default:
+		return "words"
+	}
+}
+
+// Get a source from its name.
+func parseSource(name string) (Source, error) {
+	for _, s := range sources {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+
+	return WORDLIST, fmt.Errorf("unknown source: %v", name)
+}
+
+// Allows the source to be read from the config file by name.
+func (s *Source) UnmarshalText(text []byte) error {
+	source, err := parseSource(string(text))
+	*s = source
+	return err
+}
+
+// Allows the source to be written to the config file by name.
+func (s Source) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Number of texts kept from each online source, for when it can't be reached.
+const cacheSize = 50
+
+// Matches markup left in text fetched online: citation marks like "[1]" or
+// "[citation needed]", and text in parentheses, which is mostly
+// pronunciations and dates that are awkward to type.
+var markupPattern = regexp.MustCompile(`\[[^\]]*\]|\([^)]*\)`)
+
+// Get a prompt from an online source. Everything fetched is cached, and if
+// the source can't be reached, a text from the cache is used instead.
+func fetchText(settings Settings, home string) (string, error) {
+	var texts []string
+	var err error
+
+	switch settings.source {
+	case WIKIPEDIA:
+		var text string
+		text, err = randomExtract()
+		texts = []string{text}
+	case RSS:
+		texts, err = headlines(settings.feeds)
+	default:
+		return "", fmt.Errorf("%v is not an online source", settings.source)
+	}
+
+	// Without a cache directory, texts are cached with the user's data.
+	dir, dirErr := cacheDir()
+	if dirErr != nil {
+		dir = home
+	}
+
+	name := filepath.Join(dir, settings.source.String()+".cache.json")
+	cache, cacheErr := loadCache(name)
+
+	if err != nil {
+		if len(cache) == 0 {
+			return "", err
+		}
+
+		return cache[rand.Intn(len(cache))], nil
+	}
+
+	for i, text := range texts {
+		texts[i] = clean(text)
+	}
+
+	if cacheErr == nil {
+		_ = saveCache(name, merge(texts, cache))
+	}
+
+	return texts[rand.Intn(len(texts))], nil
+}
+
+// Combine newly fetched texts with the cached ones, newest first, keeping only
+// the most recent.
+func merge(texts []string, cache []string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+
+	for _, text := range append(texts, cache...) {
+		if !seen[text] && len(merged) < cacheSize {
+			seen[text] = true
+			merged = append(merged, text)
+		}
+	}
+
+	return merged
+}
+
+// Remove markup from text fetched online, and make it typeable.
+func clean(text string) string {
+	text = markupPattern.ReplaceAllString(text, "")
+	text = strings.Join(strings.Fields(sanitize(text)), " ")
+
+	// Removing parentheses leaves a space before the punctuation that
+	// followed them.
+	for _, mark := range []string{",", ".", ";", ":"} {
+		text = strings.ReplaceAll(text, " "+mark, mark)
+	}
+
+	return text
+}
+
+// Get the texts cached in a file.
+func loadCache(name string) ([]string, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var texts []string
+	if err := json.Unmarshal(data, &texts); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return texts, nil
+}
+
+// Save texts to a cache file.
+func saveCache(name string, texts []string) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(texts, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(name, data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Represents where saved results are kept.
+type Store interface {
+	Load() ([]Result, error)                  // Get every result, oldest first
+	Page(offset, limit int) ([]Result, error) // Get up to limit results, newest first, skipping the offset newest
+	Save(r Result) error                      // Add a result to the end of the history
+	Replace(results []Result) error           // Swap the whole history for results
+}
+
+// Represents the kind of store results are kept in.
+type Backend int16
+
+const (
+	JSONL  Backend = iota // One JSON object per line in a plain text file
+	SQLITE                // SQLite database, for querying results with SQL
+	BOLT                  // bbolt key/value database
+)
+
+// Every backend, in the order they are listed to the user.
+var backends = []Backend{JSONL, SQLITE, BOLT}
+
+// Get the name of a backend, as used on the command line and in the config
+// file.
+func (b Backend) String() string {
+	switch b {
+	case SQLITE:
+		return "sqlite"
+	case BOLT:
+		return "bolt"
+	default:
+		return "jsonl"
+	}
+}
+
+// Get a backend from its name.
+func parseBackend(name string) (Backend, error) {
+	for _, b := range backends {
+		if b.String() == name {
+			return b, nil
+		}
+	}
+
+	return JSONL, fmt.Errorf("unknown storage backend: %v", name)
+}
+
+// Allows the backend to be read from the config file by name.
+func (b *Backend) UnmarshalText(text []byte) error {
+	backend, err := parseBackend(string(text))
+	*b = backend
+	return err
+}
+
+// Get the name of the file the backend keeps results in.
+func (b Backend) file() string {
+	switch b {
+	case SQLITE:
+		return sqliteFile
+	case BOLT:
+		return boltFile
+	default:
+		return historyFile
+	}
+}
+
+// Get the store for the results saved in dir. The history is migrated to the
+// current format first, if needed. A database that doesn't exist yet starts out
+// with the results in history.jsonl, so switching backends keeps the history.
+func openStore(backend Backend, dir string) (Store, error) {
+	if err := migrateHistory(dir); err != nil {
+		return nil, fmt.Errorf("failed to migrate history: %v", err)
+	}
+
+	if backend == JSONL {
+		return jsonlStore{dir}, nil
+	}
+
+	path := filepath.Join(dir, backend.file())
+	_, err := os.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	created := err != nil
+
+	var store Store
+	switch backend {
+	case SQLITE:
+		if store, err = openSQLite(path); err != nil {
+			return nil, err
+		}
+	default:
+		store = boltStore{path}
+	}
+
+	if created {
+		if err := importHistory(store, dir); err != nil {
+			return nil, fmt.Errorf("failed to import history: %v", err)
+		}
+	}
+
+	return store, nil
+}
+
+// Copy the results in history.jsonl to store, if there are any.
+func importHistory(store Store, dir string) error {
+	results, err := loadResults(dir)
+	if err != nil || len(results) == 0 {
+		return err
+	}
+
+	return store.Replace(results)
+}
+
+// Keeps results in history.jsonl.
+type jsonlStore struct {
+	dir string
+}
+
+func (s jsonlStore) Load() ([]Result, error) {
+	return loadResults(s.dir)
+}
+
+func (s jsonlStore) Page(offset int, limit int) ([]Result, error) {
+	return loadPage(s.dir, offset, limit)
+}
+
+func (s jsonlStore) Save(r Result) error {
+	return saveResult(s.dir, r)
+}
+
+func (s jsonlStore) Replace(results []Result) error {
+	return writeLines(filepath.Join(s.dir, historyFile), results)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Name of the database file used by the bbolt backend.
+const boltFile = "history.bolt"
+
+// Name of the bucket holding every result, keyed by the order they were saved
+// in.
+var resultsBucket = []byte("results")
+
+// Keeps results in a bbolt database, as JSON values.
+type boltStore struct {
+	path string
+}
+
+// Open the database, creating it if needed. Gives up if another instance of
+// the program is holding it open for too long.
+func (s boltStore) open() (*bolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	db, err := bolt.Open(s.path, 0o644, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	return db, nil
+}
+
+func (s boltStore) Load() ([]Result, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var results []Result
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(resultsBucket)
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(_ []byte, v []byte) error {
+			var r Result
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("failed to parse json: %v", err)
+			}
+
+			results = append(results, r)
+			return nil
+		})
+	})
+
+	return results, err
+}
+
+func (s boltStore) Page(offset int, limit int) ([]Result, error) {
+	db, err := s.open()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var results []Result
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(resultsBucket)
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		_, v := c.Last()
+		for i := 0; i < offset && v != nil; i++ {
+			_, v = c.Prev()
+		}
+
+		for ; v != nil && len(results) < limit; _, v = c.Prev() {
+			var r Result
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("failed to parse json: %v", err)
+			}
+
+			results = append(results, r)
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+func (s boltStore) Save(r Result) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(resultsBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+
+		return put(b, r)
+	})
+}
+
+func (s boltStore) Replace(results []Result) error {
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(resultsBucket) != nil {
+			if err := tx.DeleteBucket(resultsBucket); err != nil {
+				return fmt.Errorf("failed to delete bucket: %v", err)
+			}
+		}
+
+		b, err := tx.CreateBucket(resultsBucket)
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+
+		for _, r := range results {
+			if err := put(b, r); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Add a result to the end of the bucket. Keys are big-endian sequence numbers,
+// so iterating over the bucket returns results in the order they were saved.
+func put(b *bolt.Bucket, r Result) error {
+	id, err := b.NextSequence()
+	if err != nil {
+		return fmt.Errorf("failed to get next key: %v", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	key := binary.BigEndian.AppendUint64(nil, id)
+	if err := b.Put(key, data); err != nil {
+		return fmt.Errorf("failed to save result: %v", err)
+	}
+
+	return nil
+}
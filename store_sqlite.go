@@ -0,0 +1,227 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Name of the database file used by the SQLite backend.
+const sqliteFile = "history.db"
+
+// Changes to the database, oldest first. The database's user_version is the
+// number of them that have been applied, so new ones must only ever be added to
+// the end.
+var sqliteMigrations = []string{
+	// Creates the results table, with one column per field so the history can
+	// be queried with any SQLite client.
+	`
+CREATE TABLE IF NOT EXISTS results (
+	id         INTEGER PRIMARY KEY,
+	time       TEXT NOT NULL,
+	mode       TEXT NOT NULL,
+	duration   INTEGER NOT NULL,
+	language   TEXT NOT NULL,
+	difficulty TEXT NOT NULL,
+	elapsed    REAL NOT NULL,
+	wpm        REAL NOT NULL,
+	raw        REAL NOT NULL,
+	accuracy   REAL NOT NULL,
+	correct    INTEGER NOT NULL,
+	mistakes   INTEGER NOT NULL,
+	level      INTEGER NOT NULL,
+	layout     TEXT NOT NULL,
+	keyboard   TEXT NOT NULL
+)`,
+	`ALTER TABLE results ADD COLUMN incomplete INTEGER NOT NULL DEFAULT 0`,
+	// Stores the settings of each test as JSON, since they change more often
+	// than the rest of the result. Older results have none.
+	`ALTER TABLE results ADD COLUMN settings TEXT`,
+	// Version of the formulas each result was scored with. Older results have 0.
+	`ALTER TABLE results ADD COLUMN scoring INTEGER NOT NULL DEFAULT 0`,
+	// Marks results of tests failed by their difficulty. Older results passed.
+	`ALTER TABLE results ADD COLUMN failed INTEGER NOT NULL DEFAULT 0`,
+	// Accuracy of the shifted symbols in number row drills. Other tests have 0.
+	`ALTER TABLE results ADD COLUMN shifted REAL NOT NULL DEFAULT 0`,
+}
+
+const insertResult = `
+INSERT INTO results (time, mode, duration, language, difficulty, elapsed, wpm, raw, accuracy, correct, mistakes, level, layout, keyboard, incomplete, settings, scoring, failed, shifted)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const selectResults = `
+SELECT time, mode, duration, language, difficulty, elapsed, wpm, raw, accuracy, correct, mistakes, level, layout, keyboard, incomplete, settings, scoring, failed, shifted
+FROM results
+ORDER BY id`
+
+const selectPage = `
+SELECT time, mode, duration, language, difficulty, elapsed, wpm, raw, accuracy, correct, mistakes, level, layout, keyboard, incomplete, settings, scoring, failed, shifted
+FROM results
+ORDER BY id DESC
+LIMIT ? OFFSET ?`
+
+// Keeps results in a SQLite database.
+type sqliteStore struct {
+	db *sql.DB
+}
+
+// Open the database, creating or upgrading it if needed. It's kept open for as
+// long as the program runs.
+func openSQLite(path string) (sqliteStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return sqliteStore{}, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return sqliteStore{}, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return sqliteStore{}, err
+	}
+
+	return sqliteStore{db}, nil
+}
+
+// Apply the migrations the database hasn't seen yet.
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to get database version: %v", err)
+	}
+
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database is at version %v, but this build only understands up to version %v", version, len(sqliteMigrations))
+	}
+
+	for i, migration := range sqliteMigrations[version:] {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start transaction: %v", err)
+		}
+
+		if _, err := tx.Exec(migration); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate database: %v", err)
+		}
+
+		// PRAGMA doesn't accept parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to set database version: %v", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (s sqliteStore) Load() ([]Result, error) {
+	return s.query(selectResults)
+}
+
+func (s sqliteStore) Page(offset int, limit int) ([]Result, error) {
+	return s.query(selectPage, limit, offset)
+}
+
+// Get the results a query selects.
+func (s sqliteStore) query(query string, args ...any) ([]Result, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query results: %v", err)
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		var t string
+		var settings sql.NullString
+		err := rows.Scan(&t, &r.Mode, &r.Duration, &r.Language, &r.Difficulty, &r.Elapsed, &r.WPM, &r.Raw, &r.Accuracy, &r.Correct, &r.Mistakes, &r.Level, &r.Layout, &r.Keyboard, &r.Incomplete, &settings, &r.Scoring, &r.Failed, &r.Shifted)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read result: %v", err)
+		}
+
+		if settings.Valid {
+			if err := json.Unmarshal([]byte(settings.String), &r.Settings); err != nil {
+				return nil, fmt.Errorf("failed to parse settings: %v", err)
+			}
+		}
+
+		if r.Time, err = time.Parse(time.RFC3339Nano, t); err != nil {
+			return nil, fmt.Errorf("failed to parse time: %v", err)
+		}
+
+		results = append(results, r)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results: %v", err)
+	}
+
+	return results, nil
+}
+
+func (s sqliteStore) Save(r Result) error {
+	return insert(s.db, r)
+}
+
+func (s sqliteStore) Replace(results []Result) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM results"); err != nil {
+		return fmt.Errorf("failed to delete results: %v", err)
+	}
+
+	for _, r := range results {
+		if err := insert(tx, r); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// Represents anything SQL statements can be run on, e.g. a database or a
+// transaction.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// Add a single row to the results table.
+func insert(db execer, r Result) error {
+	var settings sql.NullString
+	if r.Settings != nil {
+		data, err := json.Marshal(r.Settings)
+		if err != nil {
+			return fmt.Errorf("failed to encode json: %v", err)
+		}
+
+		settings = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := db.Exec(insertResult, r.Time.Format(time.RFC3339Nano), r.Mode, r.Duration, r.Language, r.Difficulty, r.Elapsed, r.WPM, r.Raw, r.Accuracy, r.Correct, r.Mistakes, r.Level, r.Layout, r.Keyboard, r.Incomplete, settings, r.Scoring, r.Failed, r.Shifted)
+	if err != nil {
+		return fmt.Errorf("failed to save result: %v", err)
+	}
+
+	return nil
+}
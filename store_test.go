@@ -0,0 +1,86 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Save a result to every backend and read it back.
+func roundTrip(t *testing.T, r Result) map[Backend]Result {
+	t.Helper()
+
+	loaded := make(map[Backend]Result)
+	for _, backend := range backends {
+		store, err := openStore(backend, t.TempDir())
+		if err != nil {
+			t.Fatalf("%v: failed to open store: %v", backend, err)
+		}
+
+		if err := store.Save(r); err != nil {
+			t.Fatalf("%v: failed to save result: %v", backend, err)
+		}
+
+		results, err := store.Load()
+		if err != nil {
+			t.Fatalf("%v: failed to load results: %v", backend, err)
+		}
+
+		if len(results) != 1 {
+			t.Fatalf("%v: got %v results, want 1", backend, len(results))
+		}
+
+		loaded[backend] = results[0]
+	}
+
+	return loaded
+}
+
+func TestStoreKeepsScoring(t *testing.T) {
+	r := Result{Time: time.Now(), Mode: "time", WPM: 80, Scoring: scoringVersion}
+	for backend, got := range roundTrip(t, r) {
+		if got.Scoring != scoringVersion {
+			t.Errorf("%v: scoring version reloaded as %v, want %v", backend, got.Scoring, scoringVersion)
+		}
+	}
+}
+
+func TestStoreKeepsFailed(t *testing.T) {
+	r := Result{Time: time.Now(), Mode: "time", Difficulty: "master", WPM: 80, Failed: true}
+	for backend, got := range roundTrip(t, r) {
+		if !got.Failed {
+			t.Errorf("%v: failed result reloaded as passed", backend)
+		}
+	}
+}
+
+func TestStoreKeepsShifted(t *testing.T) {
+	r := Result{Time: time.Now(), Mode: "time", Language: numberRowLanguage, Shifted: 87.5}
+	for backend, got := range roundTrip(t, r) {
+		if got.Shifted != r.Shifted {
+			t.Errorf("%v: shifted accuracy reloaded as %v, want %v", backend, got.Shifted, r.Shifted)
+		}
+	}
+}
+
+func TestStoreImportsHistory(t *testing.T) {
+	for _, backend := range backends[1:] {
+		dir := t.TempDir()
+		if err := saveResult(dir, Result{Time: time.Now(), Mode: "time", WPM: 80}); err != nil {
+			t.Fatalf("failed to save result: %v", err)
+		}
+
+		store, err := openStore(backend, dir)
+		if err != nil {
+			t.Fatalf("%v: failed to open store: %v", backend, err)
+		}
+
+		results, err := store.Load()
+		if err != nil {
+			t.Fatalf("%v: failed to load results: %v", backend, err)
+		}
+
+		if len(results) != 1 || results[0].WPM != 80 {
+			t.Errorf("%v: got %v results after switching backends, want the 1 in history.jsonl", backend, len(results))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Number of recent keystrokes checked for signs of a layout switch.
+const switchWindow = 10
+
+// Mistakes needed within the window before a layout switch is suspected.
+const switchMistakes = 6
+
+// Share of those mistakes that must be explained by a single pair of layouts.
+const switchExplained = 0.8
+
+// Represents a single key press during the test.
+type keystroke struct {
+	expected string // Grapheme the prompt asked for
+	typed    string // Grapheme the user typed
+}
+
+// Look for a burst of mistakes that is explained by the computer's keyboard
+// layout having changed, e.g. every "s" coming out as "o" after switching
+// from QWERTY to Dvorak. Returns a description of the switch, if one is found.
+func detectLayoutSwitch(recent []keystroke) (string, bool) {
+	if len(recent) < switchWindow {
+		return "", false
+	}
+
+	recent = recent[len(recent)-switchWindow:]
+
+	var mistakes []keystroke
+	for _, k := range recent {
+		if k.typed != k.expected {
+			mistakes = append(mistakes, k)
+		}
+	}
+
+	if len(mistakes) < switchMistakes {
+		return "", false
+	}
+
+	for _, from := range layouts {
+		for _, to := range layouts {
+			if from.name == to.name {
+				continue
+			}
+
+			explained := 0
+			for _, k := range mistakes {
+				// Keys only ever type a single character.
+				expected, typed := []rune(k.expected), []rune(k.typed)
+				if len(expected) != 1 || len(typed) != 1 {
+					continue
+				}
+
+				c, ok := translate(from, to, expected[0])
+				if ok && c == unicode.ToLower(typed[0]) {
+					explained++
+				}
+			}
+
+			if float64(explained) >= float64(len(mistakes))*switchExplained {
+				return fmt.Sprintf("%v → %v", from.name, to.name), true
+			}
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Name given to --file to read the text from stdin.
+const stdinName = "-"
+
+// Get the text to type from a file, or from stdin if name is "-".
+func readTextFile(name string) (string, error) {
+	if name == stdinName {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %v", err)
+		}
+
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return string(data), nil
+}
+
+// Report whether text is being piped into the program, rather than it being
+// started from a terminal.
+func stdinPiped() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// Split text into parts of the given number of words, typed one test at a
+// time.
+func splitChunks(text string, words int) []string {
+	fields := strings.Fields(text)
+
+	var chunks []string
+	for len(fields) > 0 {
+		n := min(words, len(fields))
+		chunks = append(chunks, strings.Join(fields[:n], " "))
+		fields = fields[n:]
+	}
+
+	return chunks
+}
+
+// Report whether there is another part of the text left to type.
+func (m Model) nextChunk() bool {
+	return m.settings.chunk+1 < len(m.settings.chunks)
+}
+
+// Get a model for the next part of the text.
+func (m Model) startNextChunk() Model {
+	settings := m.settings
+	settings.chunk++
+	settings.text = settings.chunks[settings.chunk]
+	return newModel(settings, m.home, m.user)
+}
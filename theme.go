@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Name of the theme used when none is chosen.
+const themeDefault = "default"
+
+// Represents the styles used to draw the prompt.
+type Theme struct {
+	prompt  lipgloss.Style // Characters that have not been typed yet
+	typed   lipgloss.Style // Characters that were typed correctly
+	mistake lipgloss.Style // Characters that were typed incorrectly
+	cursor  lipgloss.Style // Character the user is on
+	accent  lipgloss.Style // Numbers on the stats screen
+}
+
+// Every theme the user can pick from. Colors have a light and a dark variant,
+// and the one matching the terminal's background is used.
+var themes = map[string]Theme{
+	"default": {
+		prompt:  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#6E6E6E", Dark: "#999999"}),
+		typed:   lipgloss.NewStyle(),
+		mistake: lipgloss.NewStyle().Background(lipgloss.AdaptiveColor{Light: "#FF8080", Dark: "#FF0000"}).Underline(true),
+		cursor:  lipgloss.NewStyle().Background(lipgloss.Color("#e2b714")).Foreground(lipgloss.Color("#000000")),
+		accent:  lipgloss.NewStyle(),
+	},
+	// Meets the WCAG AAA contrast ratio, and marks mistakes and the cursor
+	// with more than just color so they stand out for users who can't tell
+	// the colors apart.
+	"high-contrast": {
+		prompt:  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#444444", Dark: "#BBBBBB"}),
+		typed:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#000000", Dark: "#FFFFFF"}).Bold(true),
+		mistake: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "#A00000", Dark: "#FFFF00"}).Bold(true).Underline(true),
+		cursor:  lipgloss.NewStyle().Reverse(true),
+		accent:  lipgloss.NewStyle().Bold(true),
+	},
+}
+
+// Decide whether the light or dark variant of the theme's colors is used.
+// Accepts "light", "dark", or "auto" to ask the terminal.
+func setBackground(name string) error {
+	switch name {
+	case "light":
+		lipgloss.SetHasDarkBackground(false)
+	case "dark":
+		lipgloss.SetHasDarkBackground(true)
+	case "auto":
+		// Ask now, while the terminal can still answer; once the program
+		// starts reading input, the response would be mistaken for keys.
+		lipgloss.HasDarkBackground()
+	default:
+		return fmt.Errorf("unknown background: %v (available: auto, light, dark)", name)
+	}
+
+	return nil
+}
+
+// Get a theme by name.
+func lookupTheme(name string) (Theme, error) {
+	theme, ok := themes[name]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown theme: %v (available: %v)", name, strings.Join(themeNames(), ", "))
+	}
+
+	return theme, nil
+}
+
+// Get the names of every theme, sorted alphabetically.
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Represents how the timer is shown during a test.
+type TimerDisplay int16
+
+const (
+	REMAINING TimerDisplay = iota // Seconds left before the time runs out
+	ELAPSED                       // Seconds since the user started typing
+	BOTH                          // Seconds elapsed and left
+	HIDDEN                        // No timer at all
+)
+
+// Every way of showing the timer, in the order they are listed to the user.
+var timerDisplays = []TimerDisplay{REMAINING, ELAPSED, BOTH, HIDDEN}
+
+// Get the name of a timer display, as used on the command line and in the
+// config file.
+func (t TimerDisplay) String() string {
+	switch t {
+	case ELAPSED:
+		return "elapsed"
+	case BOTH:
+		return "both"
+	case HIDDEN:
+		return "hidden"
+	default:
+		return "remaining"
+	}
+}
+
+// Get a timer display from its name.
+func parseTimerDisplay(name string) (TimerDisplay, error) {
+	for _, t := range timerDisplays {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+
+	return REMAINING, fmt.Errorf("unknown timer display: %v", name)
+}
+
+// Allows the timer display to be read from the config file by name.
+func (t *TimerDisplay) UnmarshalText(text []byte) error {
+	display, err := parseTimerDisplay(string(text))
+	*t = display
+	return err
+}
+
+// Render the timer. Tests without a time limit can only count up, so they
+// always show the elapsed time unless the timer is hidden.
+func (m Model) timerView() string {
+	elapsed := int(m.test.Elapsed().Seconds())
+	remaining := int(m.test.Limit().Seconds()) - elapsed
+
+	display := m.settings.timer
+	if m.test.Limit() == 0 && display != HIDDEN {
+		display = ELAPSED
+	}
+
+	switch display {
+	case ELAPSED:
+		return fmt.Sprint(elapsed)
+	case BOTH:
+		return fmt.Sprintf("%vs elapsed, %vs left", elapsed, remaining)
+	case HIDDEN:
+		return ""
+	default:
+		return fmt.Sprint(remaining)
+	}
+}
+
+// Render the speed and accuracy so far, while the user is typing, unless the
+// user would rather not be distracted by them. Accuracy is left out without a
+// prompt, since nothing typed can be wrong.
+func (m Model) liveView() string {
+	if m.test.State() != engine.TYPING || m.mode == KIDS || m.settings.noDistractions {
+		return ""
+	}
+
+	score := m.test.Score()
+	if m.test.Free() {
+		return fmt.Sprintf("%.0f wpm", score.WPM)
+	}
+
+	return fmt.Sprintf("%.0f wpm, %.0f%% acc", score.WPM, score.Accuracy)
+}
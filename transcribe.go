@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nicdgonzalez/typing-tui/engine"
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Represents how a transcription lines up with the text it was copied from.
+type alignment struct {
+	matches       int // Characters typed as they are in the text
+	substitutions int // Characters typed in place of a different one
+	insertions    int // Characters typed that aren't in the text
+	deletions     int // Characters of the text that were left out
+}
+
+// Get the number of mistakes in the transcription.
+func (a alignment) errors() int {
+	return a.substitutions + a.insertions + a.deletions
+}
+
+// Line up a transcription with the text it was copied from, with as few
+// mistakes as possible (edit distance), one grapheme at a time. The user may
+// stop before the end of the text, so the part of it that was never reached
+// doesn't count as left out.
+func align(reference string, typed string) alignment {
+	ref, in := graphemes(reference), graphemes(typed)
+
+	// Every cell holds the best alignment of the typed characters so far with
+	// the first j characters of the text.
+	prev := make([]alignment, len(ref)+1)
+	for j := range prev {
+		prev[j] = alignment{deletions: j}
+	}
+
+	better := func(a alignment, b alignment) bool {
+		if a.errors() != b.errors() {
+			return a.errors() < b.errors()
+		}
+
+		return a.matches > b.matches
+	}
+
+	for i := 1; i <= len(in); i++ {
+		cur := make([]alignment, len(ref)+1)
+		cur[0] = prev[0]
+		cur[0].insertions++
+
+		for j := 1; j <= len(ref); j++ {
+			best := prev[j-1]
+			if in[i-1] == ref[j-1] {
+				best.matches++
+			} else {
+				best.substitutions++
+			}
+
+			inserted := prev[j]
+			inserted.insertions++
+			if better(inserted, best) {
+				best = inserted
+			}
+
+			deleted := cur[j-1]
+			deleted.deletions++
+			if better(deleted, best) {
+				best = deleted
+			}
+
+			cur[j] = best
+		}
+
+		prev = cur
+	}
+
+	best := prev[0]
+	for _, a := range prev[1:] {
+		if better(a, best) {
+			best = a
+		}
+	}
+
+	return best
+}
+
+// Split text into graphemes, normalized so they can be compared.
+func graphemes(text string) []string {
+	var gs []string
+
+	g := uniseg.NewGraphemes(norm.NFC.String(text))
+	for g.Next() {
+		gs = append(gs, g.Str())
+	}
+
+	return gs
+}
+
+// Get the score of a transcription from how it lines up with the text,
+// instead of character by character.
+func (m Model) transcriptionScore(score engine.Score) engine.Score {
+	aligned := engine.NewScore(m.aligned.matches, m.aligned.errors(), score.Elapsed)
+	aligned.Raw = score.Raw
+	return aligned
+}
+
+// Render the text to copy, above a line that separates it from the
+// transcription.
+func (m Model) referenceView() string {
+	width := m.promptWidth()
+	text := lipgloss.NewStyle().Width(width).Render(m.reference)
+	return text + "\n" + strings.Repeat("─", width) + "\n\n"
+}
+
+// Render how the transcription lined up with the text.
+func (m Model) alignmentView() string {
+	a := m.aligned
+	return fmt.Sprintf(
+		"Alignment: %v matched, %v wrong, %v extra, %v left out\n",
+		a.matches,
+		a.substitutions,
+		a.insertions,
+		a.deletions,
+	)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rivo/uniseg"
+)
+
+// Name of the file that lists everyone who uses the program on this machine.
+const usersFile = "users.json"
+
+// Longest name a user can pick.
+const maxNameLength = 20
+
+// Represents someone taking tests on a shared machine.
+type user struct {
+	Name string `json:"name"`
+	Salt string `json:"salt,omitempty"` // Random value mixed into the PIN hash
+	PIN  string `json:"pin,omitempty"`  // Hash of the user's PIN, if they set one
+}
+
+// Represents the steps of the login screen.
+type LoginStep int16
+
+const (
+	ENTER_NAME LoginStep = iota // User is typing their name
+	ENTER_PIN                   // User is typing their PIN
+)
+
+// Represents the user-switch screen shown in multi-user mode.
+type loginForm struct {
+	users []user    // Everyone who has logged in on this machine
+	step  LoginStep // Field being filled in
+	name  string    // Name typed so far
+	pin   string    // PIN typed so far
+	err   string    // Problem with what was entered
+}
+
+// Get the directory where a single user's stats are stored.
+func userDir(home string, name string) string {
+	return filepath.Join(home, "users", name)
+}
+
+// Get everyone who has logged in on this machine.
+func loadUsers(home string) ([]user, error) {
+	data, err := os.ReadFile(filepath.Join(home, usersFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var users []user
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return users, nil
+}
+
+// Write the list of users to disk.
+func saveUsers(home string, users []user) error {
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(users, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(home, usersFile), data, 0o600); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Create a user, hashing their PIN if they chose one.
+func newUser(name string, pin string) (user, error) {
+	u := user{Name: name}
+	if pin == "" {
+		return u, nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return user{}, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	u.Salt = hex.EncodeToString(salt)
+	u.PIN = hashPIN(u.Salt, pin)
+	return u, nil
+}
+
+// Check whether a PIN matches the one the user chose.
+func (u user) checkPIN(pin string) bool {
+	if u.PIN == "" {
+		return true
+	}
+
+	return subtle.ConstantTimeCompare([]byte(hashPIN(u.Salt, pin)), []byte(u.PIN)) == 1
+}
+
+func hashPIN(salt string, pin string) string {
+	sum := sha256.Sum256([]byte(salt + pin))
+	return hex.EncodeToString(sum[:])
+}
+
+// Names are used as directory names, so only allow a safe set of characters.
+func validateName(name string) error {
+	if name == "" {
+		return errors.New("please enter a name")
+	}
+
+	if len(name) > maxNameLength {
+		return fmt.Errorf("names can be at most %v characters", maxNameLength)
+	}
+
+	for _, c := range name {
+		isLetter := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && !isDigit && c != '-' && c != '_' {
+			return errors.New("names may only contain letters, numbers, '-' and '_'")
+		}
+	}
+
+	return nil
+}
+
+// Builds the user-switch screen.
+func loginModel(settings Settings, home string) Model {
+	m := Model{settings: settings, home: home, view: LOGIN}
+
+	users, err := loadUsers(home)
+	if err != nil {
+		m.login.err = err.Error()
+	}
+
+	m.login.users = users
+	return m
+}
+
+// Find a user by name.
+func (f loginForm) find(name string) (user, bool) {
+	for _, u := range f.users {
+		if strings.EqualFold(u.Name, name) {
+			return u, true
+		}
+	}
+
+	return user{}, false
+}
+
+// Manages the user-switch screen.
+func (m Model) updateLogin(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+
+		case "esc":
+			if m.login.step == ENTER_PIN {
+				m.login.step = ENTER_NAME
+				m.login.pin = ""
+				m.login.err = ""
+				return m, nil
+			}
+
+			return m, tea.Quit
+
+		case "enter":
+			return m.submitLogin()
+
+		case "backspace":
+			if m.login.step == ENTER_NAME && len(m.login.name) > 0 {
+				m.login.name = trimLastGrapheme(m.login.name)
+			} else if m.login.step == ENTER_PIN && len(m.login.pin) > 0 {
+				m.login.pin = trimLastGrapheme(m.login.pin)
+			}
+
+		default:
+			if m.login.step == ENTER_NAME {
+				m.login.name += string(msg.Runes)
+			} else {
+				m.login.pin += string(msg.Runes)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// Moves to the next step of the login screen, starting the test once the
+// user has been identified.
+func (m Model) submitLogin() (tea.Model, tea.Cmd) {
+	f := &m.login
+	f.err = ""
+
+	if f.step == ENTER_NAME {
+		f.name = strings.TrimSpace(f.name)
+		if err := validateName(f.name); err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+
+		if u, ok := f.find(f.name); ok && u.PIN == "" {
+			return newModel(m.settings, m.home, u.Name), nil
+		}
+
+		f.step = ENTER_PIN
+		return m, nil
+	}
+
+	if u, ok := f.find(f.name); ok {
+		if !u.checkPIN(f.pin) {
+			f.err = "wrong PIN"
+			f.pin = ""
+			return m, nil
+		}
+
+		return newModel(m.settings, m.home, u.Name), nil
+	}
+
+	u, err := newUser(f.name, f.pin)
+	if err != nil {
+		f.err = err.Error()
+		return m, nil
+	}
+
+	if err := saveUsers(m.home, append(f.users, u)); err != nil {
+		f.err = err.Error()
+		return m, nil
+	}
+
+	return newModel(m.settings, m.home, u.Name), nil
+}
+
+// Render the user-switch screen.
+func (m Model) loginView() string {
+	f := m.login
+	s := "Who's typing?\n\n"
+
+	if len(f.users) == 0 {
+		s += "No users yet. Enter a name to create one.\n\n"
+	} else {
+		names := make([]string, len(f.users))
+		for i, u := range f.users {
+			names[i] = u.Name
+		}
+
+		s += fmt.Sprintf("Users: %v\n\n", strings.Join(names, ", "))
+	}
+
+	if f.step == ENTER_NAME {
+		s += fmt.Sprintf("Name: %v%v\n", f.name, m.cursorBlock())
+	} else {
+		s += fmt.Sprintf("Name: %v\n", f.name)
+
+		hint := ""
+		if _, ok := f.find(f.name); !ok {
+			hint = " (optional, press Enter to skip)"
+		}
+
+		s += fmt.Sprintf("PIN%v: %v%v\n", hint, strings.Repeat("*", uniseg.GraphemeClusterCount(f.pin)), m.cursorBlock())
+	}
+
+	if f.err != "" {
+		s += fmt.Sprintf("\n%v\n", m.settings.theme.mistake.Render(f.err))
+	}
+
+	s += "\nPress Enter to continue, ESC to go back"
+	return s
+}
+
+// Remove the last character a user would see in s, however many bytes or
+// code points it's made of, e.g. an accented letter or an emoji.
+func trimLastGrapheme(s string) string {
+	last := 0
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		last, _ = g.Positions()
+	}
+
+	return s[:last]
+}
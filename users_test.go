@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Send keys to the login screen, as typed or pasted.
+func typeLogin(m Model, keys ...tea.KeyMsg) Model {
+	for _, k := range keys {
+		next, _ := m.updateLogin(k)
+		m = next.(Model)
+	}
+
+	return m
+}
+
+func TestLoginBackspaceErasesWholeCharacters(t *testing.T) {
+	backspace := tea.KeyMsg{Type: tea.KeyBackspace}
+	tests := []struct {
+		typed string // What's typed in the field
+		want  string // What's left after one backspace
+	}{
+		{"1234", "123"},
+		{"12\u00e9", "12"},               // An accented letter as one code point
+		{"12e\u0301", "12"},              // e followed by an accent
+		{"12\U0001F44D\U0001F3FD", "12"}, // Emoji with a skin tone
+		{"你好", "你"},                      // Wide characters
+	}
+
+	for _, tt := range tests {
+		typed := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.typed)}
+
+		m := typeLogin(Model{view: LOGIN}, typed, backspace)
+		if m.login.name != tt.want {
+			t.Errorf("name %q after backspace = %q, want %q", tt.typed, m.login.name, tt.want)
+		}
+
+		m = Model{view: LOGIN}
+		m.login.step = ENTER_PIN
+		m = typeLogin(m, typed, backspace)
+		if m.login.pin != tt.want {
+			t.Errorf("PIN %q after backspace = %q, want %q", tt.typed, m.login.pin, tt.want)
+		}
+
+		mask := strings.Repeat("*", len([]rune(tt.want)))
+		if view := m.loginView(); !strings.Contains(view, "PIN (optional, press Enter to skip): "+mask+m.cursorBlock()) {
+			t.Errorf("PIN %q after backspace is shown as:\n%v\nwant %v asterisks", tt.typed, view, len([]rune(tt.want)))
+		}
+	}
+}
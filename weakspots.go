@@ -0,0 +1,440 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Name of the file that stores the user's bigram speeds and word accuracy.
+const weakSpotsFile = "weakspots.json"
+
+// Pauses between two keys longer than this are hesitations, not typing speed,
+// so they aren't counted toward bigram speeds.
+const maxBigramGap = 2 * time.Second
+
+// A bigram or word needs to be typed at least this many times overall before
+// it is listed as a weak spot.
+const minWeakAttempts = 3
+
+// Number of items listed in each section of the weak spots screen.
+const weakSpotsShown = 5
+
+// Keys that start the drill for each item on the weak spots screen, in order.
+// Q is left out, since it quits.
+const drillKeys = "abcdefghijklmnoprstuvwxyz"
+
+// Fingers of a touch typist, in the order they are listed to the user.
+var fingers = []string{
+	"left pinky",
+	"left ring",
+	"left middle",
+	"left index",
+	"right index",
+	"right middle",
+	"right ring",
+	"right pinky",
+}
+
+// Counts how fast a pair of characters is typed one after the other.
+type bigramStat struct {
+	Count   int     `json:"count"`   // Times the pair was typed correctly
+	Seconds float64 `json:"seconds"` // Total time between the two keys
+}
+
+// Counts how often a word is typed correctly.
+type wordStat struct {
+	Attempts int `json:"attempts"` // Times the word was finished
+	Misses   int `json:"misses"`   // Times it was finished with a mistake
+}
+
+// Represents what the user has typed overall, kept to find their weak spots.
+// Per-character accuracy is kept with their proficiency instead.
+type weakSpots struct {
+	Bigrams map[string]*bigramStat `json:"bigrams"`
+	Words   map[string]*wordStat   `json:"words"`
+}
+
+// Represents a single weak spot, along with the drill that practices it.
+type weakItem struct {
+	label  string   // What the weak spot is, e.g. "th"
+	detail string   // How weak it is, e.g. "412 ms"
+	drill  []string // Every word of the drill contains one of these
+}
+
+// Represents the weak spots screen.
+type weakSpotsPage struct {
+	sections []weakSection
+	err      error // Problem reading the user's progress
+}
+
+// Represents a group of weak spots found the same way.
+type weakSection struct {
+	title string
+	items []weakItem
+}
+
+// Get the weak spots saved in dir. Returns an empty set if nothing has been
+// saved yet.
+func loadWeakSpots(dir string) (weakSpots, error) {
+	w := weakSpots{
+		Bigrams: make(map[string]*bigramStat),
+		Words:   make(map[string]*wordStat),
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, weakSpotsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return w, nil
+	} else if err != nil {
+		return w, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	if err := json.Unmarshal(data, &w); err != nil {
+		return w, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return w, nil
+}
+
+// Write the user's weak spots to dir.
+func (w weakSpots) save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(w, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode json: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(dir, weakSpotsFile), data, 0o644); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Add the bigrams and words of a test. Only pairs of characters typed
+// correctly one right after the other count toward bigram speeds.
+func (w weakSpots) record(rec *recorder) {
+	for i := 1; i < len(rec.keystrokes); i++ {
+		prev, cur := rec.keystrokes[i-1], rec.keystrokes[i]
+		if cur.Position != prev.Position+1 || !prev.Correct || !cur.Correct {
+			continue
+		}
+
+		if strings.TrimSpace(prev.Expected) == "" || strings.TrimSpace(cur.Expected) == "" {
+			continue
+		}
+
+		gap := cur.Time.Sub(prev.Time)
+		if gap <= 0 || gap > maxBigramGap {
+			continue
+		}
+
+		pair := strings.ToLower(prev.Expected + cur.Expected)
+		stat, ok := w.Bigrams[pair]
+		if !ok {
+			stat = &bigramStat{}
+			w.Bigrams[pair] = stat
+		}
+
+		stat.Count++
+		stat.Seconds += gap.Seconds()
+	}
+
+	for _, word := range rec.words {
+		key := strings.ToLower(strings.TrimFunc(word.Word, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}))
+
+		if key == "" {
+			continue
+		}
+
+		stat, ok := w.Words[key]
+		if !ok {
+			stat = &wordStat{}
+			w.Words[key] = stat
+		}
+
+		stat.Attempts++
+		if !word.Correct {
+			stat.Misses++
+		}
+	}
+}
+
+// Get the bigrams the user types slowest, slowest first.
+func (w weakSpots) slowestBigrams(n int) []weakItem {
+	var pairs []string
+	for pair, stat := range w.Bigrams {
+		if stat.Count >= minWeakAttempts {
+			pairs = append(pairs, pair)
+		}
+	}
+
+	average := func(pair string) float64 {
+		return w.Bigrams[pair].Seconds / float64(w.Bigrams[pair].Count)
+	}
+
+	sort.Slice(pairs, func(i int, j int) bool {
+		return average(pairs[i]) > average(pairs[j])
+	})
+
+	var items []weakItem
+	for _, pair := range pairs[:min(n, len(pairs))] {
+		items = append(items, weakItem{
+			label:  pair,
+			detail: fmt.Sprintf("%.0f ms", average(pair)*1000),
+			drill:  []string{pair},
+		})
+	}
+
+	return items
+}
+
+// Get the words the user gets wrong most often, worst first.
+func (w weakSpots) worstWords(n int) []weakItem {
+	var words []string
+	for word, stat := range w.Words {
+		if stat.Attempts >= minWeakAttempts && stat.Misses > 0 {
+			words = append(words, word)
+		}
+	}
+
+	rate := func(word string) float64 {
+		return float64(w.Words[word].Misses) / float64(w.Words[word].Attempts)
+	}
+
+	sort.Slice(words, func(i int, j int) bool {
+		return rate(words[i]) > rate(words[j])
+	})
+
+	var items []weakItem
+	for _, word := range words[:min(n, len(words))] {
+		items = append(items, weakItem{
+			label:  word,
+			detail: fmt.Sprintf("%.0f%% missed", rate(word)*100),
+			drill:  []string{word},
+		})
+	}
+
+	return items
+}
+
+// Get the characters the user mistypes most often, worst first.
+func (p proficiency) mostMissed(n int) []weakItem {
+	var chars []string
+	for s, c := range p {
+		if c.Misses > 0 {
+			chars = append(chars, s)
+		}
+	}
+
+	rate := func(s string) float64 {
+		return float64(p[s].Misses) / float64(p[s].Attempts)
+	}
+
+	sort.Slice(chars, func(i int, j int) bool {
+		return rate(chars[i]) > rate(chars[j])
+	})
+
+	var items []weakItem
+	for _, s := range chars[:min(n, len(chars))] {
+		items = append(items, weakItem{
+			label:  s,
+			detail: fmt.Sprintf("%.0f%% missed", rate(s)*100),
+			drill:  []string{s},
+		})
+	}
+
+	return items
+}
+
+// Get the finger a touch typist presses a key with, as an index into fingers.
+// The number row sits half a key to the left of the letters, so its keys are
+// shifted over by one.
+func finger(row int, col int) int {
+	if row == 0 {
+		col = max(col-1, 0)
+	}
+
+	switch {
+	case col <= 3:
+		return col
+	case col == 4:
+		return 3
+	case col <= 6:
+		return 4
+	default:
+		return min(col-2, len(fingers)-1)
+	}
+}
+
+// Get the accuracy of every finger that has typed something, worst first.
+func (p proficiency) fingerStats(layout Layout) []weakItem {
+	attempts := make([]int, len(fingers))
+	misses := make([]int, len(fingers))
+	keys := make([][]string, len(fingers))
+
+	for s, c := range p {
+		row, col, ok := layout.position([]rune(s)[0])
+		if !ok {
+			continue
+		}
+
+		f := finger(row, col)
+		attempts[f] += c.Attempts
+		misses[f] += c.Misses
+		keys[f] = append(keys[f], s)
+	}
+
+	var order []int
+	for f := range fingers {
+		if attempts[f] > 0 {
+			order = append(order, f)
+		}
+	}
+
+	rate := func(f int) float64 {
+		return float64(misses[f]) / float64(attempts[f])
+	}
+
+	sort.SliceStable(order, func(i int, j int) bool {
+		return rate(order[i]) > rate(order[j])
+	})
+
+	var items []weakItem
+	for _, f := range order {
+		sort.Strings(keys[f])
+		items = append(items, weakItem{
+			label:  fingers[f],
+			detail: fmt.Sprintf("%.1f%% accuracy", 100-rate(f)*100),
+			drill:  keys[f],
+		})
+	}
+
+	return items
+}
+
+// Create a model that shows the user's weak spots.
+func weakSpotsModel(settings Settings, home string, dir string, store Store, name string) Model {
+	m := Model{
+		settings: settings,
+		home:     home,
+		dir:      dir,
+		store:    store,
+		user:     name,
+		view:     WEAKSPOTS,
+	}
+
+	w, err := loadWeakSpots(dir)
+	if err != nil {
+		m.weakSpots.err = err
+		return m
+	}
+
+	p, err := loadProficiency(dir)
+	if err != nil {
+		m.weakSpots.err = err
+		return m
+	}
+
+	// Fingers are only known for layouts the program knows about.
+	layout, err := lookupLayout(settings.layout)
+	if err != nil {
+		layout, _ = lookupLayout(keycapsDefault)
+	}
+
+	m.weakSpots.sections = []weakSection{
+		{"Slowest bigrams", w.slowestBigrams(weakSpotsShown)},
+		{"Most missed characters", p.mostMissed(weakSpotsShown)},
+		{"Worst words", w.worstWords(weakSpotsShown)},
+		{"Fingers", p.fingerStats(layout)},
+	}
+
+	return m
+}
+
+// Get every item on the weak spots screen, in the order they are listed.
+func (v weakSpotsPage) items() []weakItem {
+	var items []weakItem
+	for _, section := range v.sections {
+		items = append(items, section.items...)
+	}
+
+	return items[:min(len(items), len(drillKeys))]
+}
+
+// Manages the weak spots screen. Pressing the key next to an item starts a
+// drill for it.
+func (m Model) updateWeakSpots(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc", "q":
+			return m, tea.Quit
+		}
+
+		i := strings.Index(drillKeys, msg.String())
+		items := m.weakSpots.items()
+		if len(msg.Runes) != 1 || i < 0 || i >= len(items) {
+			return m, nil
+		}
+
+		settings := m.settings
+		settings.weakSpots = false
+		settings.drill = items[i].drill
+		return newModel(settings, m.home, m.user), nil
+	}
+
+	return m, nil
+}
+
+// Render the user's weak spots, each with the key that drills it.
+func (m Model) weakSpotsView() string {
+	v := m.weakSpots
+	s := "Weak spots\n\n"
+
+	if v.err != nil {
+		return s + fmt.Sprintf("Failed to get your progress: %v\n", v.err)
+	}
+
+	if len(v.items()) == 0 {
+		return s + "Nothing to show yet. Finish a few tests to find your weak spots!\n"
+	}
+
+	key := 0
+	for _, section := range v.sections {
+		if len(section.items) == 0 {
+			continue
+		}
+
+		s += m.settings.theme.accent.Render(section.title) + "\n"
+		for _, item := range section.items {
+			if key >= len(drillKeys) {
+				break
+			}
+
+			s += fmt.Sprintf("  %c  %-14v %v\n", drillKeys[key], item.label, item.detail)
+			key++
+		}
+
+		s += "\n"
+	}
+
+	s += "Press a letter to drill it, or q to quit"
+	return s
+}
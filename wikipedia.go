@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Returns the summary of a random Wikipedia article.
+const wikipediaURL = "https://en.wikipedia.org/api/rest_v1/page/random/summary"
+
+// How long to wait for online sources before giving up.
+const fetchTimeout = 5 * time.Second
+
+// Identifies the program to online sources, as Wikipedia asks clients to.
+const userAgent = "typing-tui (https://github.com/nicdgonzalez/typing-tui)"
+
+// Represents the parts of a Wikipedia page summary the program uses.
+type wikipediaSummary struct {
+	Title   string `json:"title"`
+	Extract string `json:"extract"` // First paragraph of the article, as plain text
+}
+
+// Get the first paragraph of a random Wikipedia article.
+func randomExtract() (string, error) {
+	body, err := get(wikipediaURL)
+	if err != nil {
+		return "", err
+	}
+
+	var summary wikipediaSummary
+	if err := json.Unmarshal(body, &summary); err != nil {
+		return "", fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	if summary.Extract == "" {
+		return "", errors.New("article has no summary")
+	}
+
+	return summary.Extract, nil
+}
+
+// Download the contents of a URL.
+func get(url string) ([]byte, error) {
+	client := http.Client{Timeout: fetchTimeout}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %v: %v", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %v: %v", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Word lists smaller than this many bytes are parsed quickly enough that
+// they aren't worth caching.
+const wordCacheMin = 256 * 1024
+
+// Folder in the cache directory where parsed word lists are kept.
+const wordCacheFolder = "words"
+
+// Parse a word list read from the file with the given name. Large lists are
+// cached one word per line, which is much faster to read back than JSON, and
+// the cached copy is used for as long as the list's contents stay the same.
+func parseWords(name string, data []byte) ([]string, error) {
+	cache := ""
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	if len(data) >= wordCacheMin {
+		if dir, err := cacheDir(); err == nil {
+			cache = wordCachePath(dir, name)
+			if words, ok := loadWordCache(cache, hash); ok {
+				return words, nil
+			}
+		}
+	}
+
+	var words []string
+	if err := json.Unmarshal(data, &words); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	// The cache only makes starting faster, so the list is still usable if it
+	// can't be written.
+	if cache != "" {
+		saveWordCache(cache, hash, words)
+	}
+
+	return words, nil
+}
+
+// Get where the parsed copy of a word list is cached, one file per list.
+func wordCachePath(dir string, name string) string {
+	if abs, err := filepath.Abs(name); err == nil {
+		name = abs
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	return filepath.Join(dir, wordCacheFolder, hex.EncodeToString(sum[:8])+".txt")
+}
+
+// Read a cached word list, if it was cached from a list with the given hash.
+// The first line of the file is the hash, and every line after it a word.
+func loadWordCache(name string, hash string) ([]string, bool) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, false
+	}
+
+	first, rest, _ := strings.Cut(string(data), "\n")
+	if first != hash {
+		return nil, false
+	}
+
+	if rest == "" {
+		return []string{}, true
+	}
+
+	return strings.Split(rest, "\n"), true
+}
+
+// Cache a parsed word list. Lists with a word that spans several lines can't
+// be cached one word per line, so they're left out.
+func saveWordCache(name string, hash string, words []string) {
+	for _, w := range words {
+		if strings.Contains(w, "\n") {
+			return
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return
+	}
+
+	data := hash + "\n" + strings.Join(words, "\n")
+	writeFile(name, []byte(data), 0o644)
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Directory the word lists are read from. Replaced when the program starts,
+// once the directory flags are known.
+var wordsDir = "words"
+
+// Joins the names of word lists that are mixed into one prompt, e.g.
+// "english+spanish".
+const languageSeparator = "+"
+
+// Copies of the word lists that ship with the program, used unless a list of
+// the same name is put in the words directory, so the program works wherever
+// it's installed.
+//
+//go:embed words/*.json
+var builtinLists embed.FS
+
+// Returned when a word list, or what's left of it after filtering, e.g. by its
+// manifest or for kids mode, has nothing to build a prompt from.
+var errNoWords = errors.New("no words left to pick from")
+
+// Represents the choices offered when a word list can't be loaded.
+type wordsError struct {
+	language  string   // Name of the word list that failed to load
+	err       error    // Why it failed
+	languages []string // Other word lists that are installed
+}
+
+// Report whether the list that failed to load can be removed from the words
+// directory. Mixed lists are left alone, since it's not clear which one failed.
+func (e wordsError) removable() bool {
+	return !strings.Contains(e.language, languageSeparator) && isInstalled(e.language)
+}
+
+// Get the path of a word list, which is either a file named after the
+// language or the words file in its folder.
+func wordsPath(language string) string {
+	if isLanguageDir(language) {
+		return filepath.Join(languageDir(language), wordsFile)
+	}
+
+	return filepath.Join(wordsDir, language+".json")
+}
+
+// Get the words of every language in the settings, or the built-in list if
+// asked to.
+func loadWords(settings Settings) ([]string, error) {
+	if settings.builtinWords {
+		return getBuiltinWords(languageDefault)
+	}
+
+	var lists [][]string
+	for _, language := range strings.Split(settings.language, languageSeparator) {
+		words, err := languageWords(language)
+		if err != nil {
+			return nil, err
+		}
+
+		manifest, err := loadManifest(language)
+		if err != nil {
+			return nil, err
+		}
+
+		lists = append(lists, manifest.filter(words))
+	}
+
+	words := mixWords(lists)
+	if len(words) == 0 {
+		return nil, errNoWords
+	}
+
+	return words, nil
+}
+
+// Get the words of a single word list. Lists that ship with the program are
+// built in, so they're only read from the words directory if they're there.
+func languageWords(language string) ([]string, error) {
+	if isBuiltin(language) && !hasWordsFile(language) {
+		return getBuiltinWords(language)
+	}
+
+	return getWords(wordsPath(language))
+}
+
+// Report whether a word list ships with the program.
+func isBuiltin(language string) bool {
+	_, err := builtinLists.Open(builtinPath(language))
+	return err == nil
+}
+
+// Get the path of a built-in word list.
+func builtinPath(language string) string {
+	return "words/" + language + ".json"
+}
+
+// Get the names of the word lists that ship with the program.
+func builtinLanguages() []string {
+	entries, err := builtinLists.ReadDir("words")
+	if err != nil {
+		return nil
+	}
+
+	var languages []string
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+
+	return languages
+}
+
+// Report whether a word list is in the words directory.
+func hasWordsFile(language string) bool {
+	_, err := os.Stat(wordsPath(language))
+	return err == nil
+}
+
+// Get the words of a list built into the program.
+func getBuiltinWords(language string) ([]string, error) {
+	data, err := builtinLists.ReadFile(builtinPath(language))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read built-in list: %v", err)
+	}
+
+	var words []string
+	if err := json.Unmarshal(data, &words); err != nil {
+		return nil, fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	return words, nil
+}
+
+// Combine word lists by taking one word from each in turn. Every list is
+// sorted from most to least common, so the result is too, and the most common
+// words of every list end up near the top regardless of how long each list is.
+// Words found in more than one list are only kept once.
+func mixWords(lists [][]string) []string {
+	if len(lists) == 1 {
+		return lists[0]
+	}
+
+	seen := make(map[string]bool)
+	var words []string
+
+	for i := 0; ; i++ {
+		done := true
+		for _, list := range lists {
+			if i >= len(list) {
+				continue
+			}
+
+			done = false
+			if !seen[list[i]] {
+				seen[list[i]] = true
+				words = append(words, list[i])
+			}
+		}
+
+		if done {
+			return words
+		}
+	}
+}
+
+// Get the names of every word list and language folder in the words
+// directory, along with the built-in lists. The default list comes first.
+func installedLanguages() []string {
+	languages := []string{languageDefault}
+	for _, language := range builtinLanguages() {
+		if language != languageDefault {
+			languages = append(languages, language)
+		}
+	}
+
+	entries, err := os.ReadDir(wordsDir)
+	if err != nil {
+		return languages
+	}
+
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() {
+			name, ok = entry.Name(), true
+		}
+
+		if ok && !slices.Contains(languages, name) {
+			languages = append(languages, name)
+		}
+	}
+
+	return languages
+}
+
+// Report whether a word list, or a language folder, is in the words directory.
+func isInstalled(language string) bool {
+	return hasWordsFile(language) || isLanguageDir(language)
+}
+
+// Copy a word list, or a language folder, into the words directory so it can
+// be picked with --language. It's named after the file unless a name is given.
+// Returns the name it was installed as.
+func installWords(src string, name string) (string, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %v", err)
+	}
+
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(src), ".json")
+	}
+
+	if name == "" || name == "." || strings.ContainsAny(name, languageSeparator+`/\`) {
+		return "", fmt.Errorf("invalid name: %q", name)
+	}
+
+	if isInstalled(name) {
+		return "", fmt.Errorf("%v is already installed; remove it first", name)
+	}
+
+	list := src
+	if info.IsDir() {
+		list = filepath.Join(src, wordsFile)
+	}
+
+	data, err := os.ReadFile(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %v", err)
+	}
+
+	var words []string
+	if err := json.Unmarshal(data, &words); err != nil {
+		return "", fmt.Errorf("failed to parse json: %v", err)
+	}
+
+	if len(words) == 0 {
+		return "", errNoWords
+	}
+
+	if err := os.MkdirAll(wordsDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	if info.IsDir() {
+		if err := os.CopyFS(languageDir(name), os.DirFS(src)); err != nil {
+			return "", fmt.Errorf("failed to copy folder: %v", err)
+		}
+
+		return name, nil
+	}
+
+	if err := writeFile(filepath.Join(wordsDir, name+".json"), data, 0o644); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// Delete a word list, or a language folder, from the words directory. A list
+// that ships with the program is then read from the built-in copy again.
+func removeWords(language string) error {
+	switch {
+	case isLanguageDir(language):
+		if err := os.RemoveAll(languageDir(language)); err != nil {
+			return fmt.Errorf("failed to remove folder: %v", err)
+		}
+	case hasWordsFile(language):
+		if err := os.Remove(wordsPath(language)); err != nil {
+			return fmt.Errorf("failed to remove file: %v", err)
+		}
+	case isBuiltin(language):
+		return fmt.Errorf("%v is built in and can't be removed", language)
+	default:
+		return fmt.Errorf("%v is not installed", language)
+	}
+
+	return nil
+}
+
+// Create a model that explains why the word list couldn't be loaded, instead
+// of crashing before the UI appears.
+func wordsErrorModel(settings Settings, home string, name string, err error) Model {
+	var languages []string
+	for _, language := range installedLanguages() {
+		if language != settings.language {
+			languages = append(languages, language)
+		}
+	}
+
+	return Model{
+		settings: settings,
+		home:     home,
+		user:     name,
+		missing:  wordsError{language: settings.language, err: err, languages: languages},
+		view:     WORDS,
+	}
+}
+
+// Manages the screen shown when the word list couldn't be loaded. The first
+// choice is always the built-in list, followed by the other installed lists.
+// A broken list in the words directory can also be removed from here.
+func (m Model) updateWordsError(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tickMsg:
+		return m, tick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+		case "r":
+			if !m.missing.removable() {
+				return m, nil
+			}
+
+			if err := removeWords(m.missing.language); err != nil {
+				m.missing.err = err
+				return m, nil
+			}
+
+			return newModel(m.settings, m.home, m.user), nil
+		}
+
+		if len(msg.Runes) != 1 || msg.Runes[0] < '1' || msg.Runes[0] > '9' {
+			return m, nil
+		}
+
+		choice := int(msg.Runes[0] - '1')
+		settings := m.settings
+
+		switch {
+		case choice == 0:
+			settings.language = languageDefault
+			settings.builtinWords = true
+		case choice <= len(m.missing.languages):
+			settings.language = m.missing.languages[choice-1]
+		default:
+			return m, nil
+		}
+
+		return newModel(settings, m.home, m.user), nil
+	}
+
+	return m, nil
+}
+
+// Render the word list error screen.
+func (m Model) wordsErrorView() string {
+	s := fmt.Sprintf("Couldn't load the %v word list: %v\n\n", m.missing.language, m.missing.err)
+	s += fmt.Sprintf("1) Use the built-in %v list\n", languageDefault)
+
+	for i, language := range m.missing.languages {
+		if i+2 > 9 {
+			break
+		}
+
+		s += fmt.Sprintf("%v) Use %v\n", i+2, languageName(language))
+	}
+
+	if m.missing.removable() {
+		s += fmt.Sprintf("r) Remove the %v list from %v\n", m.missing.language, wordsDir)
+	}
+
+	s += "\nTo add a list, run: typing-tui words install <file>"
+	s += "\nPress a number to choose, or ESC to quit"
+	return s
+}
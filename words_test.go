@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallAndRemoveWords(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "dutch.json")
+	if err := os.WriteFile(src, []byte(`["een", "twee"]`), 0o644); err != nil {
+		t.Fatalf("failed to write list: %v", err)
+	}
+
+	old := wordsDir
+	wordsDir = t.TempDir()
+	defer func() { wordsDir = old }()
+
+	name, err := installWords(src, "")
+	if err != nil {
+		t.Fatalf("failed to install list: %v", err)
+	}
+
+	words, err := languageWords(name)
+	if err != nil || len(words) != 2 {
+		t.Fatalf("installed list %v has %v words (%v), want 2", name, len(words), err)
+	}
+
+	if _, err := installWords(src, ""); err == nil {
+		t.Errorf("installing %v twice didn't fail", name)
+	}
+
+	if err := removeWords(name); err != nil {
+		t.Fatalf("failed to remove list: %v", err)
+	}
+
+	if isInstalled(name) {
+		t.Errorf("%v is still installed after removing it", name)
+	}
+
+	if err := removeWords(languageDefault); err == nil {
+		t.Errorf("removing the built-in %v list didn't fail", languageDefault)
+	}
+}
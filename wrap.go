@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/nicdgonzalez/typing-tui/engine"
+	"github.com/rivo/uniseg"
+)
+
+// Drawn at the end of a line where a word too long to fit on one is split.
+const wrapMarker = `\`
+
+// Lines of a long prompt shown at a time.
+const promptLines = 3
+
+// Represents how the line of the prompt ends after a cell.
+type lineEnd int16
+
+const (
+	NO_BREAK lineEnd = iota // The line goes on
+	WRAP                    // The line ends between two words
+	SPLIT                   // The line ends in the middle of a word, with a marker
+)
+
+// Decide where the lines of the prompt end, filling each line with as many
+// words as fit in width. Lines end after a space or a hyphen, so hyphenated
+// words can be broken too. A word longer than a whole line is split, leaving
+// room for the marker. Only the drawing changes: the cells stay as they are,
+// so the cursor is unaffected.
+func wrapPrompt(cells []engine.Cell, width int) []lineEnd {
+	ends := make([]lineEnd, len(cells))
+	col := 0
+
+	for start := 0; start < len(cells); {
+		// Find the next place a line could end, and how wide the word before
+		// it is, leaving out a trailing space that can hang past the edge.
+		end := start
+		for end < len(cells) && !breakable(cells[end]) {
+			end++
+		}
+
+		end = min(end+1, len(cells))
+		visible := 0
+		for _, cell := range cells[start:end] {
+			if !cell.Space() {
+				visible += uniseg.StringWidth(cell.Expected)
+			}
+		}
+
+		if col > 0 && col+visible > width {
+			ends[start-1] = WRAP
+			col = 0
+		}
+
+		consumed := 0
+		for i := start; i < end; i++ {
+			if cells[i].Expected == "\n" {
+				col = 0
+				continue
+			}
+
+			w := uniseg.StringWidth(cells[i].Expected)
+			if !cells[i].Space() {
+				if col > 0 && col+visible-consumed > width && col+w > width-len(wrapMarker) {
+					ends[i-1] = SPLIT
+					col = 0
+				}
+
+				consumed += w
+			}
+
+			col += w
+		}
+
+		start = end
+	}
+
+	return ends
+}
+
+// Render the prompt, wrapped to the terminal. A long prompt only shows a few
+// lines at a time, with the line the cursor is on in the middle, so the text
+// scrolls up a line whenever the cursor moves to the next one. Code is always
+// shown in full, since its lines belong together.
+func (m Model) promptView() string {
+	cells := m.test.Cells()
+	ends := wrapPrompt(cells, m.promptWidth())
+	lines := []string{""}
+	current := -1
+
+	for i, cell := range cells {
+		if i == m.test.Cursor() {
+			current = len(lines) - 1
+		}
+
+		// Code keeps its own line breaks.
+		if cell.Expected == "\n" {
+			lines[len(lines)-1] += m.renderCell(i, "↵")
+			lines = append(lines, "")
+			continue
+		}
+
+		lines[len(lines)-1] += m.renderCell(i, cell.Expected)
+
+		switch ends[i] {
+		case WRAP:
+			lines = append(lines, "")
+		case SPLIT:
+			lines[len(lines)-1] += m.settings.theme.prompt.Render(wrapMarker)
+			lines = append(lines, "")
+		}
+	}
+
+	// Without a prompt, the cursor sits after whatever was typed last.
+	if current == -1 {
+		current = len(lines) - 1
+	}
+
+	if m.test.Free() && m.test.State() != engine.DONE {
+		lines[len(lines)-1] += m.cursorBlock()
+	}
+
+	if !m.settings.fullPrompt && m.mode != CODE && len(lines) > promptLines {
+		start := min(max(current-(promptLines-1)/2, 0), len(lines)-promptLines)
+		lines = lines[start : start+promptLines]
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Get how wide the lines of the prompt can be: as wide as the terminal, less a
+// column for the cursor to sit at the end of a line, or a default width until
+// the terminal's size is known.
+func (m Model) promptWidth() int {
+	if m.width <= 1 {
+		return terminalWidthDefault
+	}
+
+	return m.width - 1
+}
+
+// Report whether a line can end after the cell.
+func breakable(cell engine.Cell) bool {
+	return cell.Space() || cell.Expected == "-"
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/nicdgonzalez/typing-tui/engine"
+)
+
+// Language saved with results of zen mode, which has no word list.
+const zenLanguage = "free typing"
+
+// Create a model for zen mode, where the user types whatever they like until
+// they press ESC.
+func zenModel(settings Settings, home string, dir string, store Store, name string) Model {
+	test := engine.NewFree(time.Duration(settings.duration)*time.Second, engine.SystemClock{})
+	keys := newKeyLog()
+	test.Subscribe(keys.observe)
+	rec := &recorder{}
+	test.Subscribe(rec.observe)
+	score := &combo{}
+	test.Subscribe(score.observe)
+
+	var spent ledger
+	if settings.budget.enabled() {
+		results, err := store.Load()
+		if err != nil {
+			log.Fatalf("failed to get history: %v", err)
+		}
+
+		spent = newLedger(results, time.Now())
+	}
+
+	var demo *engine.Typist
+	if settings.demo {
+		demo = newDemoTypist(settings.demoWPM)
+	}
+
+	return Model{
+		test:     test,
+		demo:     demo,
+		ledger:   spent,
+		watch:    newWatcher(),
+		keys:     keys,
+		recorder: rec,
+		combo:    score,
+		mode:     ZEN,
+		language: zenLanguage,
+		settings: settings,
+		home:     home,
+		dir:      dir,
+		store:    store,
+		user:     name,
+		view:     PROMPT,
+	}
+}
+
+// Get the average time between keystrokes, and how much it varies (standard
+// deviation). Pauses longer than a hesitation are left out.
+func (rec *recorder) rhythm() (time.Duration, time.Duration) {
+	var gaps []float64
+	for i := 1; i < len(rec.keystrokes); i++ {
+		gap := rec.keystrokes[i].Time.Sub(rec.keystrokes[i-1].Time)
+		if gap > 0 && gap <= maxBigramGap {
+			gaps = append(gaps, gap.Seconds())
+		}
+	}
+
+	if len(gaps) == 0 {
+		return 0, 0
+	}
+
+	mean := 0.0
+	for _, g := range gaps {
+		mean += g
+	}
+	mean /= float64(len(gaps))
+
+	variance := 0.0
+	for _, g := range gaps {
+		variance += (g - mean) * (g - mean)
+	}
+	variance /= float64(len(gaps))
+
+	seconds := func(s float64) time.Duration {
+		return time.Duration(s * float64(time.Second))
+	}
+
+	return seconds(mean), seconds(math.Sqrt(variance))
+}
+
+// Render the rhythm of the keystrokes for the stats screen.
+func (rec *recorder) rhythmView(accent lipgloss.Style) string {
+	mean, spread := rec.rhythm()
+	if mean == 0 {
+		return "Rhythm: not enough keystrokes\n"
+	}
+
+	return fmt.Sprintf(
+		"Rhythm: %v between keys (± %v)\n",
+		accent.Render(fmt.Sprintf("%v ms", mean.Milliseconds())),
+		spread.Milliseconds(),
+	)
+}